@@ -0,0 +1,209 @@
+// Command migrate converts the createdAt, updatedAt, and deletedAt timestamps of the Messages, and the revisedAt timestamps of the Revisions and the timestamps of their Messages, stored in MongoDB from RFC 3339 strings to BSON datetimes.
+// Only the documents that still have a string timestamp are read, so an interrupted migration resumes where it stopped when it is run again.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+)
+
+const (
+	dbName                  = "palindromedb"
+	collectionName          = "messages"
+	revisionsCollectionName = "revisions"
+)
+
+var (
+	defaultMongoURI  = ""
+	defaultBatchSize = 1000
+)
+
+type config struct {
+	mongoURI  string
+	batchSize int
+}
+
+// migration converts the timestamps at field in the documents of collection.
+type migration struct {
+	collection *mongo.Collection
+	// field is the dotted path of the timestamp in a document.
+	field string
+}
+
+func main() {
+	cfg, err := parseConfig(os.Args)
+	if err != nil {
+		log.Println("error parsing config:", err)
+		os.Exit(1)
+	}
+
+	client, err := mongo.NewClient(cfg.mongoURI)
+	if err != nil {
+		log.Println("error creating mongo client:", err)
+		os.Exit(1)
+	}
+	err = client.Connect(context.Background())
+	if err != nil {
+		log.Println("error connecting to mongo client:", err)
+		os.Exit(1)
+	}
+	db := client.Database(dbName)
+
+	migrations := []migration{
+		{db.Collection(collectionName), "createdAt"},
+		{db.Collection(collectionName), "updatedAt"},
+		{db.Collection(collectionName), "deletedAt"},
+		{db.Collection(revisionsCollectionName), "revisedAt"},
+		{db.Collection(revisionsCollectionName), "message.createdAt"},
+		{db.Collection(revisionsCollectionName), "message.updatedAt"},
+	}
+	var skipped int64
+	for _, m := range migrations {
+		n, err := m.run(context.Background(), cfg.batchSize)
+		if err != nil {
+			log.Printf("error migrating %s: %s", m.collection.Name(), err)
+			os.Exit(1)
+		}
+		skipped += n
+	}
+	// The skipped documents keep their strings, so they are read again by the next migration until they are fixed.
+	if skipped > 0 {
+		log.Printf("skipped %d documents with invalid timestamps", skipped)
+		os.Exit(1)
+	}
+}
+
+func parseConfig(args []string) (config, error) {
+	fsName := args[0]
+	fsArgs := args[1:]
+
+	fs := flag.NewFlagSet(fsName, flag.ExitOnError)
+	mongoURI := fs.String("mongo-uri", defaultMongoURI, "MongoDB connection string")
+	batchSize := fs.Int("batch-size", defaultBatchSize, "Number of documents read and converted at a time")
+	fs.Parse(fsArgs)
+
+	envMongoURI := os.Getenv("MONGO_URI")
+	if *mongoURI == defaultMongoURI && envMongoURI != "" {
+		*mongoURI = envMongoURI
+	}
+	if *mongoURI == "" {
+		return config{}, errors.New("missing MongoDB connection string")
+	}
+
+	var err error
+	envBatchSize := os.Getenv("BATCH_SIZE")
+	if *batchSize == defaultBatchSize && envBatchSize != "" {
+		*batchSize, err = strconv.Atoi(envBatchSize)
+		if err != nil {
+			err = fmt.Errorf(`invalid integer value "%s" for BATCH_SIZE: %s`, envBatchSize, err.Error())
+			return config{}, err
+		}
+	}
+	if *batchSize < 1 {
+		err = fmt.Errorf(`invalid batch size %d: must be at least 1`, *batchSize)
+		return config{}, err
+	}
+
+	return config{
+		*mongoURI,
+		*batchSize,
+	}, nil
+}
+
+// run converts the timestamps of m in batches of batchSize documents, logs its progress after each batch, and returns the number of documents it skipped.
+// Documents are read in the order of their IDs, so a timestamp that cannot be parsed is logged and skipped.
+func (m migration) run(ctx context.Context, batchSize int) (int64, error) {
+	name := m.collection.Name()
+	total, err := m.collection.CountDocuments(ctx, bson.NewDocument(m.isString()))
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("migrating %d documents in %s", total, name)
+	var done, skipped int64
+	var lastID *bson.Element
+	for {
+		filter := bson.NewDocument(m.isString())
+		if lastID != nil {
+			filter.Append(bson.EC.SubDocumentFromElements("_id", bson.EC.Interface("$gt", lastID.Value())))
+		}
+		cur, err := m.collection.Find(ctx, filter,
+			findopt.Sort(bson.NewDocument(bson.EC.Int32("_id", 1))),
+			findopt.Limit(int64(batchSize)),
+			findopt.Projection(bson.NewDocument(bson.EC.Int32(m.field, 1))),
+		)
+		if err != nil {
+			return skipped, err
+		}
+		n := 0
+		for cur.Next(ctx) {
+			doc := bson.NewDocument()
+			err := cur.Decode(doc)
+			if err != nil {
+				cur.Close(ctx)
+				return skipped, err
+			}
+			n++
+			lastID = doc.LookupElement("_id")
+			converted, err := m.convert(ctx, doc)
+			if err != nil {
+				cur.Close(ctx)
+				return skipped, err
+			}
+			if converted {
+				done++
+			} else {
+				skipped++
+			}
+		}
+		err = cur.Err()
+		cur.Close(ctx)
+		if err != nil {
+			return skipped, err
+		}
+		log.Printf("migrated %d of %d documents in %s, skipped %d", done, total, name, skipped)
+		if n < batchSize {
+			return skipped, nil
+		}
+	}
+}
+
+// convert replaces the string timestamp of doc by a datetime, and returns false if the timestamp cannot be parsed.
+// The timestamp is only replaced if it was not changed since doc was read.
+func (m migration) convert(ctx context.Context, doc *bson.Document) (bool, error) {
+	id := doc.LookupElement("_id")
+	raw, _ := doc.Lookup(strings.Split(m.field, ".")...).StringValueOK()
+	t, err := parseTimestamp(raw)
+	if err != nil {
+		log.Printf("skipping document with %s: %s", id, err)
+		return false, nil
+	}
+	filter := bson.NewDocument(id, bson.EC.String(m.field, raw))
+	update := bson.NewDocument(bson.EC.SubDocumentFromElements("$set", bson.EC.Time(m.field, t)))
+	_, err = m.collection.UpdateOne(ctx, filter, update)
+	return err == nil, err
+}
+
+// isString matches the documents whose timestamp is still a string.
+func (m migration) isString() *bson.Element {
+	return bson.EC.SubDocumentFromElements(m.field, bson.EC.String("$type", "string"))
+}
+
+// parseTimestamp parses a timestamp that was stored as an RFC 3339 string.
+func parseTimestamp(s string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf(`invalid timestamp "%s"`, s)
+	}
+	return t.UTC(), nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	testCases := []struct {
+		name   string
+		args   []string
+		env    map[string]string
+		want   config
+		errMsg string
+	}{
+		{
+			"args only",
+			[]string{
+				"migrate",
+				"-mongo-uri=mongodb://localhost:27017",
+				"-batch-size=100",
+			},
+			nil,
+			config{
+				"mongodb://localhost:27017",
+				100,
+			},
+			"",
+		},
+		{
+			"envs only",
+			[]string{
+				"migrate",
+			},
+			map[string]string{
+				"MONGO_URI":  "mongodb://localhost:27017",
+				"BATCH_SIZE": "100",
+			},
+			config{
+				"mongodb://localhost:27017",
+				100,
+			},
+			"",
+		},
+		{
+			"default batch size",
+			[]string{
+				"migrate",
+				"-mongo-uri=mongodb://localhost:27017",
+			},
+			nil,
+			config{
+				"mongodb://localhost:27017",
+				defaultBatchSize,
+			},
+			"",
+		},
+		{
+			"missing mongo uri",
+			[]string{
+				"migrate",
+			},
+			nil,
+			config{},
+			"missing MongoDB connection string",
+		},
+		{
+			"invalid env batch size",
+			[]string{
+				"migrate",
+				"-mongo-uri=mongodb://localhost:27017",
+			},
+			map[string]string{
+				"BATCH_SIZE": "invalid",
+			},
+			config{},
+			"invalid integer value",
+		},
+		{
+			"zero batch size",
+			[]string{
+				"migrate",
+				"-mongo-uri=mongodb://localhost:27017",
+				"-batch-size=0",
+			},
+			nil,
+			config{},
+			"invalid batch size",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			defer setEnv(getEnv(tc.env))
+			setEnv(tc.env)
+			cfg, err := parseConfig(tc.args)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, cfg)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.errMsg)
+				require.Empty(t, cfg)
+			}
+		})
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	testCases := []struct {
+		name   string
+		s      string
+		want   time.Time
+		errMsg string
+	}{
+		{
+			"RFC 3339",
+			"2018-06-01T12:00:00.123456789Z",
+			time.Date(2018, 6, 1, 12, 0, 0, 123456789, time.UTC),
+			"",
+		},
+		{
+			"offset",
+			"2018-06-01T14:00:00+02:00",
+			time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC),
+			"",
+		},
+		{
+			"invalid",
+			"yesterday",
+			time.Time{},
+			`invalid timestamp "yesterday"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseTimestamp(tc.s)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, got)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func getEnv(env map[string]string) map[string]string {
+	retEnv := make(map[string]string, len(env))
+	for k := range env {
+		v := os.Getenv(k)
+		retEnv[k] = v
+	}
+	return retEnv
+}
+
+func setEnv(env map[string]string) {
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -9,30 +10,59 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/mongodb/mongo-go-driver/mongo"
 	"github.com/nicholaslam/example-service/internal/endpoint"
+	"github.com/nicholaslam/example-service/internal/idgen"
 	"github.com/nicholaslam/example-service/internal/service"
 	"github.com/nicholaslam/example-service/internal/store"
 	"github.com/nicholaslam/example-service/internal/transport"
+	"github.com/nicholaslam/example-service/pkg/palindrome"
 )
 
 const (
-	dbName         = "palindromedb"
-	collectionName = "messages"
+	dbName                  = "palindromedb"
+	collectionName          = "messages"
+	revisionsCollectionName = "revisions"
+
+	// maxTrashPurgeInterval is the longest time between two purges of the trash.
+	maxTrashPurgeInterval = time.Hour
 )
 
 var (
-	defaultHTTPAddr         = ":8080"
-	defaultStrictPalindrome = true
-	defaultMongoURI         = ""
+	defaultHTTPAddr              = ":8080"
+	defaultStrictPalindrome      = true
+	defaultMongoURI              = ""
+	defaultPalindromeMode        = ""
+	defaultUnicodeForm           = "NFC"
+	defaultStripDiacritics       = false
+	defaultDistanceSubstitutions = false
+	defaultDNAMinSiteLength      = palindrome.DefaultMinSiteLength
+	defaultTrashRetention        = 30 * 24 * time.Hour
+	defaultIDScheme              = ""
+	defaultDataDir               = ""
+	defaultSyncPolicy            = string(store.SyncAlways)
+	defaultCompactionInterval    = time.Hour
+	defaultAcceptIDSchemes       = ""
 )
 
 type config struct {
-	httpAddr         string
-	strictPalindrome bool
-	mongoURI         string
+	httpAddr              string
+	strictPalindrome      bool
+	mongoURI              string
+	palindromeMode        service.Mode
+	unicode               palindrome.UnicodeOptions
+	distanceSubstitutions bool
+	dnaMinSiteLength      int
+	trashRetention        time.Duration
+	idScheme              string
+	dataDir               string
+	syncPolicy            string
+	compactionInterval    time.Duration
+	acceptIDSchemes       []string
 }
 
 func main() {
@@ -42,7 +72,35 @@ func main() {
 		return
 	}
 
-	str := store.NewTempStore()
+	// The schemes were validated by parseConfig.
+	ids, _ := idgen.Parse(cfg.idScheme)
+	var accepted []idgen.Generator
+	for _, scheme := range cfg.acceptIDSchemes {
+		g, _ := idgen.Parse(scheme)
+		accepted = append(accepted, g)
+	}
+	ids = idgen.Accept(ids, accepted...)
+	str := store.NewTempStore(ids)
+	if cfg.dataDir != "" {
+		// The policy was validated by parseConfig.
+		fileStore, err := store.NewFileStore(cfg.dataDir, ids, store.SyncPolicy(cfg.syncPolicy))
+		if err != nil {
+			log.Println("error opening file store:", err)
+			return
+		}
+		defer func() {
+			if err := fileStore.Close(); err != nil {
+				log.Println("error closing file store:", err)
+			}
+		}()
+		str = fileStore
+
+		compactCtx, stopCompaction := context.WithCancel(context.Background())
+		defer stopCompaction()
+		if cfg.compactionInterval > 0 {
+			go compactLog(compactCtx, fileStore, cfg.compactionInterval)
+		}
+	}
 	if cfg.mongoURI != "" {
 		client, err := mongo.NewClient(cfg.mongoURI)
 		if err != nil {
@@ -54,20 +112,47 @@ func main() {
 			log.Println("error connecting to mongo client:", err)
 			return
 		}
-		str = store.NewMongoStore(client.Database(dbName).Collection(collectionName))
+		db := client.Database(dbName)
+		str = store.NewMongoStore(db.Collection(collectionName), db.Collection(revisionsCollectionName), ids)
 	}
 
-	service := service.NewService(str, cfg.strictPalindrome)
+	service := service.NewService(str, service.Config{
+		Checkers:              palindrome.NewDefaultRegistry(cfg.unicode),
+		Mode:                  cfg.palindromeMode,
+		DistanceSubstitutions: cfg.distanceSubstitutions,
+		MinSiteLength:         cfg.dnaMinSiteLength,
+		TrashRetention:        cfg.trashRetention,
+	})
+
+	purgeCtx, stopPurge := context.WithCancel(context.Background())
+	defer stopPurge()
+	if cfg.trashRetention > 0 {
+		go purgeTrash(purgeCtx, service, cfg.trashRetention)
+	}
 
 	createEndpoint := endpoint.MakeCreateEndpoint(service)
+	checkEndpoint := endpoint.MakeCheckEndpoint(service)
 	readEndpoint := endpoint.MakeReadEndpoint(service)
+	revisionsEndpoint := endpoint.MakeRevisionsEndpoint(service)
+	updateEndpoint := endpoint.MakeUpdateEndpoint(service)
 	listEndpoint := endpoint.MakeListEndpoint(service)
 	deleteEndpoint := endpoint.MakeDeleteEndpoint(service)
+	trashEndpoint := endpoint.MakeTrashEndpoint(service)
+	restoreEndpoint := endpoint.MakeRestoreEndpoint(service)
+	uploadEndpoint := endpoint.MakeUploadEndpoint(service)
+	palindromesEndpoint := endpoint.MakePalindromesEndpoint(service)
 
 	createHandler := transport.MakeCreateHTTPHandler(createEndpoint)
-	readHandler := transport.MakeReadHTTPHandler(readEndpoint)
+	checkHandler := transport.MakeCheckHTTPHandler(checkEndpoint)
+	readHandler := transport.MakeReadHTTPHandler(readEndpoint, ids)
+	revisionsHandler := transport.MakeRevisionsHTTPHandler(revisionsEndpoint, ids)
+	updateHandler := transport.MakeUpdateHTTPHandler(updateEndpoint, ids)
 	listHandler := transport.MakeListHTTPHandler(listEndpoint)
-	deleteHandler := transport.MakeDeleteHTTPHandler(deleteEndpoint)
+	deleteHandler := transport.MakeDeleteHTTPHandler(deleteEndpoint, ids)
+	trashHandler := transport.MakeTrashHTTPHandler(trashEndpoint)
+	restoreHandler := transport.MakeRestoreHTTPHandler(restoreEndpoint, ids)
+	uploadHandler := transport.MakeUploadHTTPHandler(uploadEndpoint)
+	palindromesHandler := transport.MakePalindromesHTTPHandler(palindromesEndpoint, ids)
 
 	// Duplicate the route definitions to match trailing slash without redirecting.
 	r := mux.NewRouter()
@@ -79,10 +164,24 @@ func main() {
 	s.Methods("POST").Path("/messages/").Handler(createHandler)
 	s.Methods("GET").Path("/messages/{id}").Handler(readHandler)
 	s.Methods("GET").Path("/messages/{id}/").Handler(readHandler)
+	s.Methods("PUT", "PATCH").Path("/messages/{id}").Handler(updateHandler)
+	s.Methods("PUT", "PATCH").Path("/messages/{id}/").Handler(updateHandler)
 	s.Methods("GET").Path("/messages").Handler(listHandler)
 	s.Methods("GET").Path("/messages/").Handler(listHandler)
 	s.Methods("DELETE").Path("/messages/{id}").Handler(deleteHandler)
 	s.Methods("DELETE").Path("/messages/{id}/").Handler(deleteHandler)
+	s.Methods("POST").Path("/messages/{id}/restore").Handler(restoreHandler)
+	s.Methods("POST").Path("/messages/{id}/restore/").Handler(restoreHandler)
+	s.Methods("GET").Path("/trash").Handler(trashHandler)
+	s.Methods("GET").Path("/trash/").Handler(trashHandler)
+	s.Methods("GET").Path("/messages/{id}/palindromes").Handler(palindromesHandler)
+	s.Methods("GET").Path("/messages/{id}/palindromes/").Handler(palindromesHandler)
+	s.Methods("GET").Path("/messages/{id}/revisions").Handler(revisionsHandler)
+	s.Methods("GET").Path("/messages/{id}/revisions/").Handler(revisionsHandler)
+	s.Methods("POST").Path("/check").Handler(checkHandler)
+	s.Methods("POST").Path("/check/").Handler(checkHandler)
+	s.Methods("POST").Path("/uploads").Handler(uploadHandler)
+	s.Methods("POST").Path("/uploads/").Handler(uploadHandler)
 
 	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusNotFound)
@@ -108,7 +207,7 @@ func main() {
 		close(done)
 	}()
 
-	log.Println("http-addr", cfg.httpAddr, "strict-palindrome", cfg.strictPalindrome)
+	log.Println("http-addr", cfg.httpAddr, "palindrome-mode", cfg.palindromeMode, "unicode-form", cfg.unicode.Form, "strip-diacritics", cfg.unicode.StripDiacritics)
 	if err := srv.ListenAndServe(); err != nil {
 		log.Println(err)
 		if err != http.ErrServerClosed {
@@ -127,6 +226,17 @@ func parseConfig(args []string) (config, error) {
 	httpAddr := fs.String("http-addr", defaultHTTPAddr, "HTTP listen address")
 	strictPalindrome := fs.Bool("strict-palindrome", defaultStrictPalindrome, "Use strict definition of a palindrome")
 	mongoURI := fs.String("mongo-uri", defaultMongoURI, "MongoDB connection string. Pass empty string to use in-memory database")
+	palindromeMode := fs.String("palindrome-mode", defaultPalindromeMode, "Default palindrome mode: strict, lenient, unicode, grapheme, word, line, dna, numeric, mirror, or rotation. Pass empty string to use strict-palindrome")
+	unicodeForm := fs.String("unicode-form", defaultUnicodeForm, "Unicode normalization form used by the unicode and grapheme palindrome modes: NFC or NFD")
+	stripDiacritics := fs.Bool("strip-diacritics", defaultStripDiacritics, "Ignore diacritics in the unicode and grapheme palindrome modes")
+	distanceSubstitutions := fs.Bool("distance-substitutions", defaultDistanceSubstitutions, "Count replacing a character as a single edit when computing the distance to a palindrome")
+	dnaMinSiteLength := fs.Int("dna-min-site-length", defaultDNAMinSiteLength, "Minimum length of the reverse complement palindromic sites stored in the dna palindrome mode")
+	trashRetention := fs.Duration("trash-retention", defaultTrashRetention, "How long deleted messages are kept in the trash before they are purged. Pass 0 to keep them until they are purged explicitly")
+	idScheme := fs.String("id-scheme", defaultIDScheme, "Scheme of the IDs of new messages: uuid, objectid, or ulid. Pass empty string to use objectid in MongoDB, and uuid otherwise")
+	dataDir := fs.String("data-dir", defaultDataDir, "Directory of the write-ahead log and snapshots of the file database. Pass empty string to use in-memory database")
+	syncPolicy := fs.String("fsync", defaultSyncPolicy, "When the write-ahead log of the file database is flushed to disk: always, interval, or never")
+	compactionInterval := fs.Duration("compaction-interval", defaultCompactionInterval, "How often the write-ahead log of the file database is compacted into a snapshot. Pass 0 to disable compaction")
+	acceptIDSchemes := fs.String("accept-id-schemes", defaultAcceptIDSchemes, "Comma-separated schemes of IDs accepted in requests in addition to id-scheme, like the scheme of messages created before id-scheme was changed")
 	fs.Parse(fsArgs)
 
 	envHTTPAddr := os.Getenv("HTTP_ADDR")
@@ -149,13 +259,199 @@ func parseConfig(args []string) (config, error) {
 		*mongoURI = envMongoURI
 	}
 
+	envUnicodeForm := os.Getenv("UNICODE_FORM")
+	if *unicodeForm == defaultUnicodeForm && envUnicodeForm != "" {
+		*unicodeForm = envUnicodeForm
+	}
+	form, err := palindrome.ParseForm(*unicodeForm)
+	if err != nil {
+		err = fmt.Errorf(`invalid normalization form "%s": %s`, *unicodeForm, err.Error())
+		return config{}, err
+	}
+
+	envPalindromeMode := os.Getenv("PALINDROME_MODE")
+	if *palindromeMode == defaultPalindromeMode && envPalindromeMode != "" {
+		*palindromeMode = envPalindromeMode
+	}
+	mode := service.ModeLenient
+	if *strictPalindrome {
+		mode = service.ModeStrict
+	}
+	if *palindromeMode != "" {
+		if _, ok := palindrome.NewDefaultRegistry(palindrome.UnicodeOptions{}).Lookup(*palindromeMode); !ok {
+			err = fmt.Errorf(`invalid palindrome mode "%s": %s`, *palindromeMode, service.ErrInvalidMode.Error())
+			return config{}, err
+		}
+		mode = service.Mode(*palindromeMode)
+	}
+
+	envStripDiacritics := os.Getenv("STRIP_DIACRITICS")
+	if *stripDiacritics == defaultStripDiacritics && envStripDiacritics != "" {
+		*stripDiacritics, err = strconv.ParseBool(envStripDiacritics)
+		if err != nil {
+			err = fmt.Errorf(`invalid boolean value "%s" for STRIP_DIACRITICS: %s`, envStripDiacritics, err.Error())
+			return config{}, err
+		}
+	}
+
+	envDistanceSubstitutions := os.Getenv("DISTANCE_SUBSTITUTIONS")
+	if *distanceSubstitutions == defaultDistanceSubstitutions && envDistanceSubstitutions != "" {
+		*distanceSubstitutions, err = strconv.ParseBool(envDistanceSubstitutions)
+		if err != nil {
+			err = fmt.Errorf(`invalid boolean value "%s" for DISTANCE_SUBSTITUTIONS: %s`, envDistanceSubstitutions, err.Error())
+			return config{}, err
+		}
+	}
+
+	envDNAMinSiteLength := os.Getenv("DNA_MIN_SITE_LENGTH")
+	if *dnaMinSiteLength == defaultDNAMinSiteLength && envDNAMinSiteLength != "" {
+		*dnaMinSiteLength, err = strconv.Atoi(envDNAMinSiteLength)
+		if err != nil {
+			err = fmt.Errorf(`invalid integer value "%s" for DNA_MIN_SITE_LENGTH: %s`, envDNAMinSiteLength, err.Error())
+			return config{}, err
+		}
+	}
+	if *dnaMinSiteLength < 1 {
+		err = fmt.Errorf(`invalid DNA minimum site length %d: must be at least 1`, *dnaMinSiteLength)
+		return config{}, err
+	}
+
+	envTrashRetention := os.Getenv("TRASH_RETENTION")
+	if *trashRetention == defaultTrashRetention && envTrashRetention != "" {
+		*trashRetention, err = time.ParseDuration(envTrashRetention)
+		if err != nil {
+			err = fmt.Errorf(`invalid duration value "%s" for TRASH_RETENTION: %s`, envTrashRetention, err.Error())
+			return config{}, err
+		}
+	}
+	if *trashRetention < 0 {
+		err = fmt.Errorf(`invalid trash retention %s: must not be negative`, *trashRetention)
+		return config{}, err
+	}
+
+	envIDScheme := os.Getenv("ID_SCHEME")
+	if *idScheme == defaultIDScheme && envIDScheme != "" {
+		*idScheme = envIDScheme
+	}
+	if *idScheme == "" {
+		*idScheme = idgen.SchemeUUID
+		if *mongoURI != "" {
+			*idScheme = idgen.SchemeObjectID
+		}
+	}
+	if _, err := idgen.Parse(*idScheme); err != nil {
+		err = fmt.Errorf(`invalid ID scheme "%s": %s`, *idScheme, err.Error())
+		return config{}, err
+	}
+
+	envDataDir := os.Getenv("DATA_DIR")
+	if *dataDir == defaultDataDir && envDataDir != "" {
+		*dataDir = envDataDir
+	}
+	if *mongoURI != "" && *dataDir != "" {
+		return config{}, errors.New("mongo-uri and data-dir cannot both be set")
+	}
+
+	envSyncPolicy := os.Getenv("FSYNC")
+	if *syncPolicy == defaultSyncPolicy && envSyncPolicy != "" {
+		*syncPolicy = envSyncPolicy
+	}
+	if _, err := store.ParseSyncPolicy(*syncPolicy); err != nil {
+		err = fmt.Errorf(`invalid sync policy "%s": %s`, *syncPolicy, err.Error())
+		return config{}, err
+	}
+
+	envCompactionInterval := os.Getenv("COMPACTION_INTERVAL")
+	if *compactionInterval == defaultCompactionInterval && envCompactionInterval != "" {
+		*compactionInterval, err = time.ParseDuration(envCompactionInterval)
+		if err != nil {
+			err = fmt.Errorf(`invalid duration value "%s" for COMPACTION_INTERVAL: %s`, envCompactionInterval, err.Error())
+			return config{}, err
+		}
+	}
+	if *compactionInterval < 0 {
+		err = fmt.Errorf(`invalid compaction interval %s: must not be negative`, *compactionInterval)
+		return config{}, err
+	}
+
+	envAcceptIDSchemes := os.Getenv("ACCEPT_ID_SCHEMES")
+	if *acceptIDSchemes == defaultAcceptIDSchemes && envAcceptIDSchemes != "" {
+		*acceptIDSchemes = envAcceptIDSchemes
+	}
+	var accepted []string
+	for _, scheme := range strings.Split(*acceptIDSchemes, ",") {
+		scheme = strings.TrimSpace(scheme)
+		if scheme == "" {
+			continue
+		}
+		if _, err := idgen.Parse(scheme); err != nil {
+			err = fmt.Errorf(`invalid accepted ID scheme "%s": %s`, scheme, err.Error())
+			return config{}, err
+		}
+		accepted = append(accepted, scheme)
+	}
+
 	return config{
 		*httpAddr,
 		*strictPalindrome,
 		*mongoURI,
+		mode,
+		palindrome.UnicodeOptions{
+			Form:            form,
+			StripDiacritics: *stripDiacritics,
+		},
+		*distanceSubstitutions,
+		*dnaMinSiteLength,
+		*trashRetention,
+		*idScheme,
+		*dataDir,
+		*syncPolicy,
+		*compactionInterval,
+		accepted,
 	}, nil
 }
 
+// purgeTrash periodically purges the Messages that have been in the trash for longer than retention, until ctx is done.
+func purgeTrash(ctx context.Context, svc service.Service, retention time.Duration) {
+	interval := maxTrashPurgeInterval
+	if retention < interval {
+		interval = retention
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := svc.EmptyTrash(ctx)
+			if err != nil {
+				log.Println("error purging trash:", err)
+				continue
+			}
+			if n > 0 {
+				log.Println("purged", n, "messages from the trash")
+			}
+		}
+	}
+}
+
+// compactLog compacts the write-ahead log of fs every interval, until ctx is done.
+func compactLog(ctx context.Context, fs store.FileStore, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := fs.Compact(); err != nil {
+				log.Println("error compacting file store:", err)
+			}
+		}
+	}
+}
+
 func healthz(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("ok"))
@@ -4,6 +4,9 @@ package endpoint
 import (
 	"context"
 	"errors"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/nicholaslam/example-service/internal/service"
@@ -15,102 +18,608 @@ var (
 
 	// ErrBadRequest is returned if a request is invalid.
 	ErrBadRequest = errors.New("bad request")
+
+	// ErrPreconditionFailed is returned if a Message does not match the IfMatch Condition of a DeleteRequest.
+	ErrPreconditionFailed = errors.New("precondition failed")
 )
 
+// NotModifiedError is returned if a Message matches the IfNoneMatch Condition of a ReadRequest.
+type NotModifiedError struct {
+	// Version is the current version of the Message.
+	Version int
+}
+
+func (e NotModifiedError) Error() string {
+	return "not modified"
+}
+
+// Condition represents the versions of a Message listed in an If-Match or If-None-Match header.
+type Condition struct {
+	// Any is true for the "*" wildcard, which matches any version.
+	Any      bool
+	Versions []int
+}
+
+func (c *Condition) matches(version int) bool {
+	if c.Any {
+		return true
+	}
+	for _, v := range c.Versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
 // CreateRequest represents a payload used to create a Message.
+// Either Text or Number must be set. Number is evaluated with service.ModeNumeric.
 type CreateRequest struct {
-	Text *string `json:"text,omitempty"`
+	Text   *string `json:"text,omitempty"`
+	Number *string `json:"number,omitempty"`
+	Mode   *string `json:"mode,omitempty"`
+	Lang   *string `json:"lang,omitempty"`
+}
+
+// CheckRequest represents a payload used to evaluate texts without storing them.
+type CheckRequest struct {
+	Payloads []CreateRequest
+	// Single is true if a single CreateRequest was sent instead of an array, so a single MessageResponse is returned.
+	Single bool
 }
 
 // ReadRequest represents a payload used to read a Message.
 type ReadRequest struct {
 	ID string `json:"id"`
+	// IfNoneMatch is nil if the Message is read unconditionally.
+	IfNoneMatch *Condition `json:"-"`
+	// At reads the Message as it was at a point in time. It is nil to read the current Message.
+	At *time.Time `json:"-"`
+}
+
+// RevisionsRequest represents a payload used to list the revisions of a Message.
+type RevisionsRequest struct {
+	ID string `json:"id"`
+}
+
+// UpdateRequest represents a payload used to update a Message.
+// If Partial is false, the Message is replaced like a CreateRequest creates one. Otherwise, nil fields are left unchanged.
+type UpdateRequest struct {
+	ID      string  `json:"-"`
+	Text    *string `json:"text,omitempty"`
+	Number  *string `json:"number,omitempty"`
+	Mode    *string `json:"mode,omitempty"`
+	Lang    *string `json:"lang,omitempty"`
+	Partial bool    `json:"-"`
 }
 
 // ListRequest represents a payload used to list Messages.
 type ListRequest struct {
-	Palindrome *bool
+	Palindrome           *bool
+	MinLongestPalindrome *int
+	MaxDistance          *int
+	Rearrangeable        *bool
+	Base                 *int
+	CreatedAfter         *time.Time
+	CreatedBefore        *time.Time
+	Prefix               *string
+	Contains             *string
+	MinLength            *int
+	MaxLength            *int
+	// Sort is a field Messages are listed by, prefixed with "-" to list them in descending order.
+	Sort   *string
+	Limit  *int
+	Cursor *string
 }
 
 // DeleteRequest represents a payload used to delete a Message.
 type DeleteRequest struct {
 	ID string `json:"id"`
+	// IfMatch is nil if the Message is deleted unconditionally.
+	IfMatch *Condition `json:"-"`
+	// Purge permanently deletes the Message and its history instead of moving it to the trash.
+	Purge bool `json:"-"`
+}
+
+// TrashRequest represents a payload used to list deleted Messages.
+type TrashRequest struct{}
+
+// RestoreRequest represents a payload used to restore a deleted Message.
+type RestoreRequest struct {
+	ID string `json:"id"`
+}
+
+// PalindromesRequest represents a payload used to list the palindromic substrings of a Message.
+type PalindromesRequest struct {
+	ID        string
+	MinLength *int
+	Offset    *int
+	Limit     *int
+}
+
+// UploadRequest represents a payload used to evaluate a text without storing it.
+type UploadRequest struct {
+	Body io.Reader
+	Mode *string
+}
+
+// UploadResponse represents the evaluation of an uploaded text.
+type UploadResponse struct {
+	Palindrome bool   `json:"palindrome"`
+	Mode       string `json:"mode"`
+	Size       int64  `json:"size"`
+}
+
+// PalindromesResponse represents a page of the distinct palindromic substrings of a Message.
+type PalindromesResponse struct {
+	Palindromes []PalindromicSubstringResponse `json:"palindromes"`
+	Total       int                            `json:"total"`
+}
+
+// PalindromicSubstringResponse represents a distinct palindromic substring of a Message and its occurrences. Offsets are measured in runes.
+type PalindromicSubstringResponse struct {
+	Text   string `json:"text"`
+	Length int    `json:"length"`
+	Count  int    `json:"count"`
+	Start  int    `json:"start"`
+}
+
+// ListResponse represents a page of Messages.
+type ListResponse struct {
+	Messages []MessageResponse `json:"messages"`
+	// Next is the cursor of the next page, or empty if this is the last page.
+	Next string `json:"next,omitempty"`
 }
 
 // MessageResponse represents a single Message response.
 type MessageResponse struct {
-	ID         string `json:"id"`
-	Text       string `json:"text"`
-	Palindrome bool   `json:"palindrome"`
-	CreatedAt  string `json:"createdAt"`
+	ID                string            `json:"id,omitempty"`
+	Text              string            `json:"text"`
+	Length            int               `json:"length"`
+	Palindrome        bool              `json:"palindrome"`
+	Mode              string            `json:"mode"`
+	Lang              string            `json:"lang,omitempty"`
+	LongestPalindrome SubstringResponse `json:"longestPalindrome"`
+	Distance          *int              `json:"distance"`
+	Suggestion        string            `json:"suggestion"`
+	Rearrangeable     bool              `json:"rearrangeable"`
+	Arrangement       string            `json:"arrangement"`
+	Sites             []SiteResponse    `json:"sites,omitempty"`
+	Bases             []int             `json:"bases,omitempty"`
+	CreatedAt         string            `json:"createdAt,omitempty"`
+	UpdatedAt         string            `json:"updatedAt,omitempty"`
+	Version           int               `json:"version,omitempty"`
+	DeletedAt         string            `json:"deletedAt,omitempty"`
+}
+
+// MessageAtResponse represents a Message as it was at a point in time. Unlike a MessageResponse, it has no ETag, as it may not be the current version.
+type MessageAtResponse struct {
+	MessageResponse
+}
+
+// RevisionResponse represents a Message as it was from RevisedAt until the next revision.
+type RevisionResponse struct {
+	Message   MessageResponse `json:"message"`
+	RevisedAt string          `json:"revisedAt"`
+	Deleted   bool            `json:"deleted"`
+}
+
+// SubstringResponse represents a palindromic substring of a Message. Offsets are measured in runes.
+type SubstringResponse struct {
+	Text   string `json:"text"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Length int    `json:"length"`
+}
+
+// SiteResponse represents a reverse complement palindromic site of a Message. Offsets are measured in nucleotides.
+type SiteResponse struct {
+	Sequence string `json:"sequence"`
+	Start    int    `json:"start"`
+	End      int    `json:"end"`
+	Length   int    `json:"length"`
 }
 
 // MakeCreateEndpoint returns a new endpoint for creating Messages.
 func MakeCreateEndpoint(svc service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(CreateRequest)
-		if req.Text == nil {
-			return MessageResponse{}, ErrBadRequest
-		}
-		p := service.MessagePayload{
-			Text: *req.Text,
+		p, err := toMessagePayload(req)
+		if err != nil {
+			return MessageResponse{}, err
 		}
 		msg, err := svc.Create(ctx, p)
 		if err != nil {
-			return MessageResponse{}, err
+			return MessageResponse{}, evaluationError(err)
 		}
 		return toMessageResponse(msg), nil
 	}
 }
 
+// MakeCheckEndpoint returns a new endpoint for evaluating texts without storing them.
+func MakeCheckEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(CheckRequest)
+		var empty interface{} = []MessageResponse{}
+		if req.Single {
+			empty = MessageResponse{}
+		}
+		ps := make([]service.MessagePayload, 0, len(req.Payloads))
+		for _, r := range req.Payloads {
+			p, err := toMessagePayload(r)
+			if err != nil {
+				return empty, err
+			}
+			ps = append(ps, p)
+		}
+		msgs, err := svc.Check(ctx, ps)
+		if err != nil {
+			if err == service.ErrTooManyPayloads || err == service.ErrTooManyRunes {
+				return empty, ErrBadRequest
+			}
+			return empty, evaluationError(err)
+		}
+		if req.Single {
+			if len(msgs) != 1 {
+				return empty, ErrBadRequest
+			}
+			return toMessageResponse(msgs[0]), nil
+		}
+		res := []MessageResponse{}
+		for _, msg := range msgs {
+			res = append(res, toMessageResponse(msg))
+		}
+		return res, nil
+	}
+}
+
+// toMessagePayload validates req. A Number is evaluated with service.ModeNumeric.
+func toMessagePayload(req CreateRequest) (service.MessagePayload, error) {
+	if (req.Text == nil) == (req.Number == nil) {
+		return service.MessagePayload{}, ErrBadRequest
+	}
+	var p service.MessagePayload
+	if req.Mode != nil {
+		p.Mode = service.Mode(*req.Mode)
+	}
+	if req.Lang != nil {
+		p.Lang = *req.Lang
+	}
+	if req.Text != nil {
+		p.Text = *req.Text
+	} else {
+		if p.Mode != "" && p.Mode != service.ModeNumeric {
+			return service.MessagePayload{}, ErrBadRequest
+		}
+		p.Text = *req.Number
+		p.Mode = service.ModeNumeric
+	}
+	return p, nil
+}
+
+// evaluationError maps the errors returned if a text cannot be evaluated to ErrBadRequest.
+func evaluationError(err error) error {
+	switch err {
+	case service.ErrInvalidMode, service.ErrInvalidSequence, service.ErrInvalidNumber, service.ErrNumberTooLarge, service.ErrInvalidLanguage:
+		return ErrBadRequest
+	}
+	return err
+}
+
 // MakeReadEndpoint returns a new endpoint for reading Messages.
 func MakeReadEndpoint(svc service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(ReadRequest)
-		msg, err := svc.Read(ctx, req.ID)
+		var msg service.Message
+		var err error
+		if req.At != nil {
+			msg, err = svc.ReadAt(ctx, req.ID, *req.At)
+		} else {
+			msg, err = svc.Read(ctx, req.ID)
+		}
 		if err != nil {
 			if err == service.ErrNotFound {
 				return MessageResponse{}, ErrNotFound
 			}
 			return MessageResponse{}, err
 		}
+		if req.At != nil {
+			return MessageAtResponse{toMessageResponse(msg)}, nil
+		}
+		if req.IfNoneMatch != nil && req.IfNoneMatch.matches(msg.Version) {
+			return MessageResponse{}, NotModifiedError{msg.Version}
+		}
 		return toMessageResponse(msg), nil
 	}
 }
 
+// MakeRevisionsEndpoint returns a new endpoint for listing the revisions of Messages.
+func MakeRevisionsEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RevisionsRequest)
+		revs, err := svc.Revisions(ctx, req.ID)
+		if err != nil {
+			if err == service.ErrNotFound {
+				return []RevisionResponse{}, ErrNotFound
+			}
+			return []RevisionResponse{}, err
+		}
+		res := []RevisionResponse{}
+		for _, rev := range revs {
+			res = append(res, RevisionResponse{
+				Message:   toMessageResponse(rev.Message),
+				RevisedAt: rev.RevisedAt,
+				Deleted:   rev.Deleted,
+			})
+		}
+		return res, nil
+	}
+}
+
+// MakeUpdateEndpoint returns a new endpoint for updating Messages.
+func MakeUpdateEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UpdateRequest)
+		p, err := toUpdatePayload(req)
+		if err != nil {
+			return MessageResponse{}, err
+		}
+		msg, err := svc.Update(ctx, req.ID, p)
+		if err != nil {
+			if err == service.ErrNotFound {
+				return MessageResponse{}, ErrNotFound
+			}
+			return MessageResponse{}, evaluationError(err)
+		}
+		return toMessageResponse(msg), nil
+	}
+}
+
+// toUpdatePayload validates req. A full update is validated like a CreateRequest and sets every field.
+func toUpdatePayload(req UpdateRequest) (service.UpdatePayload, error) {
+	create := CreateRequest{
+		Text:   req.Text,
+		Number: req.Number,
+		Mode:   req.Mode,
+		Lang:   req.Lang,
+	}
+	if !req.Partial {
+		p, err := toMessagePayload(create)
+		if err != nil {
+			return service.UpdatePayload{}, err
+		}
+		return service.UpdatePayload{
+			Text: &p.Text,
+			Mode: &p.Mode,
+			Lang: &p.Lang,
+		}, nil
+	}
+	if req.Text != nil && req.Number != nil {
+		return service.UpdatePayload{}, ErrBadRequest
+	}
+	if req.Number != nil {
+		p, err := toMessagePayload(create)
+		if err != nil {
+			return service.UpdatePayload{}, err
+		}
+		return service.UpdatePayload{
+			Text: &p.Text,
+			Mode: &p.Mode,
+			Lang: req.Lang,
+		}, nil
+	}
+	var p service.UpdatePayload
+	p.Text = req.Text
+	if req.Mode != nil {
+		mode := service.Mode(*req.Mode)
+		p.Mode = &mode
+	}
+	p.Lang = req.Lang
+	return p, nil
+}
+
 // MakeListEndpoint returns a new endpoint for listing Messages.
 func MakeListEndpoint(svc service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(ListRequest)
 		p := service.ListPayload{
-			Palindrome: req.Palindrome,
+			Palindrome:           req.Palindrome,
+			MinLongestPalindrome: req.MinLongestPalindrome,
+			MaxDistance:          req.MaxDistance,
+			Rearrangeable:        req.Rearrangeable,
+			Base:                 req.Base,
+			CreatedAfter:         req.CreatedAfter,
+			CreatedBefore:        req.CreatedBefore,
+			Prefix:               req.Prefix,
+			Contains:             req.Contains,
+			MinLength:            req.MinLength,
+			MaxLength:            req.MaxLength,
 		}
-		msgs, err := svc.List(ctx, p)
+		if req.Sort != nil {
+			p.Sort = service.Sort(strings.TrimPrefix(*req.Sort, "-"))
+			p.Descending = strings.HasPrefix(*req.Sort, "-")
+		}
+		if req.Limit != nil {
+			p.Limit = *req.Limit
+		}
+		if req.Cursor != nil {
+			p.Cursor = *req.Cursor
+		}
+		page, err := svc.List(ctx, p)
 		if err != nil {
-			return []MessageResponse{}, err
+			switch err {
+			case service.ErrInvalidBase, service.ErrInvalidSort, service.ErrInvalidCursor:
+				return ListResponse{}, ErrBadRequest
+			}
+			return ListResponse{}, err
 		}
-		res := []MessageResponse{}
-		for _, msg := range msgs {
-			res = append(res, toMessageResponse(msg))
+		res := ListResponse{
+			Messages: []MessageResponse{},
+			Next:     page.Next,
+		}
+		for _, msg := range page.Messages {
+			res.Messages = append(res.Messages, toMessageResponse(msg))
 		}
 		return res, nil
 	}
 }
 
 // MakeDeleteEndpoint returns a new endpoint for deleting Messages.
+// If the request has an IfMatch Condition, the Message is only deleted if its current version matches and was not changed since it was read.
 func MakeDeleteEndpoint(svc service.Service) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(DeleteRequest)
-		err := svc.Delete(ctx, req.ID)
+		if req.IfMatch == nil {
+			err := svc.Delete(ctx, req.ID, service.DeletePayload{Purge: req.Purge})
+			if err == service.ErrNotFound {
+				return nil, ErrNotFound
+			}
+			return nil, err
+		}
+		msg, err := svc.Read(ctx, req.ID)
+		if err != nil {
+			if err == service.ErrNotFound {
+				return nil, ErrPreconditionFailed
+			}
+			return nil, err
+		}
+		if !req.IfMatch.matches(msg.Version) {
+			return nil, ErrPreconditionFailed
+		}
+		err = svc.Delete(ctx, req.ID, service.DeletePayload{Version: &msg.Version, Purge: req.Purge})
+		if err == service.ErrVersionMismatch {
+			return nil, ErrPreconditionFailed
+		}
 		return nil, err
 	}
 }
 
+// MakeTrashEndpoint returns a new endpoint for listing deleted Messages.
+func MakeTrashEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		msgs, err := svc.Trash(ctx)
+		if err != nil {
+			return []MessageResponse{}, err
+		}
+		res := []MessageResponse{}
+		for _, msg := range msgs {
+			res = append(res, toMessageResponse(msg))
+		}
+		return res, nil
+	}
+}
+
+// MakeRestoreEndpoint returns a new endpoint for restoring deleted Messages.
+func MakeRestoreEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(RestoreRequest)
+		msg, err := svc.Restore(ctx, req.ID)
+		if err != nil {
+			if err == service.ErrNotFound {
+				return MessageResponse{}, ErrNotFound
+			}
+			return MessageResponse{}, err
+		}
+		return toMessageResponse(msg), nil
+	}
+}
+
+// MakePalindromesEndpoint returns a new endpoint for listing the palindromic substrings of Messages.
+func MakePalindromesEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(PalindromesRequest)
+		var p service.PalindromesPayload
+		if req.MinLength != nil {
+			p.MinLength = *req.MinLength
+		}
+		if req.Offset != nil {
+			p.Offset = *req.Offset
+		}
+		if req.Limit != nil {
+			p.Limit = *req.Limit
+		}
+		page, err := svc.Palindromes(ctx, req.ID, p)
+		if err != nil {
+			if err == service.ErrNotFound {
+				return PalindromesResponse{}, ErrNotFound
+			}
+			return PalindromesResponse{}, err
+		}
+		res := PalindromesResponse{
+			Palindromes: []PalindromicSubstringResponse{},
+			Total:       page.Total,
+		}
+		for _, sub := range page.Palindromes {
+			res.Palindromes = append(res.Palindromes, PalindromicSubstringResponse{
+				Text:   sub.Text,
+				Length: sub.Length,
+				Count:  sub.Count,
+				Start:  sub.Start,
+			})
+		}
+		return res, nil
+	}
+}
+
+// MakeUploadEndpoint returns a new endpoint for evaluating uploaded texts.
+func MakeUploadEndpoint(svc service.Service) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(UploadRequest)
+		var mode service.Mode
+		if req.Mode != nil {
+			mode = service.Mode(*req.Mode)
+		}
+		res, err := svc.CheckStream(ctx, req.Body, mode)
+		if err != nil {
+			if err == service.ErrInvalidMode {
+				return UploadResponse{}, ErrBadRequest
+			}
+			return UploadResponse{}, err
+		}
+		return UploadResponse{
+			Palindrome: res.Palindrome,
+			Mode:       string(res.Mode),
+			Size:       res.Size,
+		}, nil
+	}
+}
+
 func toMessageResponse(msg service.Message) MessageResponse {
 	return MessageResponse{
 		ID:         msg.ID,
 		Text:       msg.Text,
+		Length:     msg.Length,
 		Palindrome: msg.Palindrome,
-		CreatedAt:  msg.CreatedAt,
+		Mode:       string(msg.Mode),
+		Lang:       msg.Lang,
+		LongestPalindrome: SubstringResponse{
+			Text:   msg.LongestPalindrome.Text,
+			Start:  msg.LongestPalindrome.Start,
+			End:    msg.LongestPalindrome.End,
+			Length: msg.LongestPalindrome.Length,
+		},
+		Distance:      msg.Distance,
+		Suggestion:    msg.Suggestion,
+		Rearrangeable: msg.Rearrangeable,
+		Arrangement:   msg.Arrangement,
+		Sites:         toSiteResponses(msg.Sites),
+		Bases:         msg.Bases,
+		CreatedAt:     msg.CreatedAt,
+		UpdatedAt:     msg.UpdatedAt,
+		Version:       msg.Version,
+		DeletedAt:     msg.DeletedAt,
+	}
+}
+
+func toSiteResponses(sites []service.Site) []SiteResponse {
+	var res []SiteResponse
+	for _, site := range sites {
+		res = append(res, SiteResponse{
+			Sequence: site.Sequence,
+			Start:    site.Start,
+			End:      site.End,
+			Length:   site.Length,
+		})
 	}
+	return res
 }
@@ -3,6 +3,9 @@ package endpoint
 import (
 	"context"
 	"errors"
+	"io"
+	"io/ioutil"
+	"strings"
 	"testing"
 	"time"
 
@@ -20,18 +23,81 @@ func (ms *mockService) Create(ctx context.Context, p service.MessagePayload) (se
 	return ms.msg, ms.err
 }
 
+func (ms *mockService) Check(ctx context.Context, ps []service.MessagePayload) ([]service.Message, error) {
+	if ms.err != nil {
+		return []service.Message{}, ms.err
+	}
+	msgs := []service.Message{}
+	for range ps {
+		msgs = append(msgs, ms.msg)
+	}
+	return msgs, nil
+}
+
 func (ms *mockService) Read(ctx context.Context, id string) (service.Message, error) {
 	return ms.msg, ms.err
 }
 
-func (ms *mockService) List(ctx context.Context, p service.ListPayload) ([]service.Message, error) {
-	return ms.msgs, ms.err
+func (ms *mockService) ReadAt(ctx context.Context, id string, at time.Time) (service.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockService) Revisions(ctx context.Context, id string) ([]service.Revision, error) {
+	if ms.err != nil {
+		return []service.Revision{}, ms.err
+	}
+	revs := []service.Revision{}
+	for _, msg := range ms.msgs {
+		revs = append(revs, service.Revision{Message: msg, RevisedAt: msg.UpdatedAt})
+	}
+	return revs, nil
+}
+
+func (ms *mockService) Update(ctx context.Context, id string, p service.UpdatePayload) (service.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockService) List(ctx context.Context, p service.ListPayload) (service.MessagesPage, error) {
+	return service.MessagesPage{Messages: ms.msgs}, ms.err
 }
 
-func (ms *mockService) Delete(ctx context.Context, id string) error {
+func (ms *mockService) Delete(ctx context.Context, id string, p service.DeletePayload) error {
 	return ms.err
 }
 
+func (ms *mockService) Trash(ctx context.Context) ([]service.Message, error) {
+	return ms.msgs, ms.err
+}
+
+func (ms *mockService) Restore(ctx context.Context, id string) (service.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockService) EmptyTrash(ctx context.Context) (int, error) {
+	return len(ms.msgs), ms.err
+}
+
+func (ms *mockService) CheckStream(ctx context.Context, r io.Reader, mode service.Mode) (service.StreamResult, error) {
+	if ms.err != nil {
+		return service.StreamResult{}, ms.err
+	}
+	b, err := ioutil.ReadAll(r)
+	return service.StreamResult{Palindrome: ms.msg.Palindrome, Mode: mode, Size: int64(len(b))}, err
+}
+
+func (ms *mockService) Palindromes(ctx context.Context, id string, p service.PalindromesPayload) (service.PalindromesPage, error) {
+	if ms.err != nil {
+		return service.PalindromesPage{}, ms.err
+	}
+	sub := service.PalindromicSubstring{
+		Text:   ms.msg.LongestPalindrome.Text,
+		Length: ms.msg.LongestPalindrome.Length,
+		Count:  1,
+		Start:  ms.msg.LongestPalindrome.Start,
+	}
+	return service.PalindromesPage{Palindromes: []service.PalindromicSubstring{sub}, Total: 1}, nil
+}
+
 func toStringPointer(s string) *string {
 	return &s
 }
@@ -40,6 +106,10 @@ func toBoolPointer(b bool) *bool {
 	return &b
 }
 
+func toIntPointer(i int) *int {
+	return &i
+}
+
 func TestMakeCreateEndpoint(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 
@@ -57,7 +127,13 @@ func TestMakeCreateEndpoint(t *testing.T) {
 					ID:         "123",
 					Text:       "racecar",
 					Palindrome: true,
-					CreatedAt:  now,
+					LongestPalindrome: service.Substring{
+						Text:   "racecar",
+						Start:  0,
+						End:    7,
+						Length: 7,
+					},
+					CreatedAt: now,
 				},
 				nil,
 				nil,
@@ -69,6 +145,144 @@ func TestMakeCreateEndpoint(t *testing.T) {
 				ID:         "123",
 				Text:       "racecar",
 				Palindrome: true,
+				LongestPalindrome: SubstringResponse{
+					Text:   "racecar",
+					Start:  0,
+					End:    7,
+					Length: 7,
+				},
+				CreatedAt: now,
+			},
+			"",
+		},
+		{
+			"mode",
+			&mockService{
+				service.Message{
+					ID:            "123",
+					Text:          "a toyota",
+					Palindrome:    true,
+					Mode:          service.ModeLenient,
+					Distance:      toIntPointer(1),
+					Suggestion:    "a toyot a",
+					Rearrangeable: true,
+					Arrangement:   "atoyota",
+					CreatedAt:     now,
+				},
+				nil,
+				nil,
+			},
+			CreateRequest{
+				Text: toStringPointer("a toyota"),
+				Mode: toStringPointer("lenient"),
+			},
+			MessageResponse{
+				ID:            "123",
+				Text:          "a toyota",
+				Palindrome:    true,
+				Mode:          "lenient",
+				Distance:      toIntPointer(1),
+				Suggestion:    "a toyot a",
+				Rearrangeable: true,
+				Arrangement:   "atoyota",
+				CreatedAt:     now,
+			},
+			"",
+		},
+		{
+			"dna",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "GAATTC",
+					Palindrome: true,
+					Mode:       service.ModeDNA,
+					Sites: []service.Site{
+						{
+							Sequence: "GAATTC",
+							Start:    0,
+							End:      6,
+							Length:   6,
+						},
+					},
+					CreatedAt: now,
+				},
+				nil,
+				nil,
+			},
+			CreateRequest{
+				Text: toStringPointer("GAATTC"),
+				Mode: toStringPointer("dna"),
+			},
+			MessageResponse{
+				ID:         "123",
+				Text:       "GAATTC",
+				Palindrome: true,
+				Mode:       "dna",
+				Sites: []SiteResponse{
+					{
+						Sequence: "GAATTC",
+						Start:    0,
+						End:      6,
+						Length:   6,
+					},
+				},
+				CreatedAt: now,
+			},
+			"",
+		},
+		{
+			"lang",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "Iı",
+					Palindrome: true,
+					Mode:       service.ModeLenient,
+					Lang:       "tr",
+					CreatedAt:  now,
+				},
+				nil,
+				nil,
+			},
+			CreateRequest{
+				Text: toStringPointer("Iı"),
+				Mode: toStringPointer("lenient"),
+				Lang: toStringPointer("tr"),
+			},
+			MessageResponse{
+				ID:         "123",
+				Text:       "Iı",
+				Palindrome: true,
+				Mode:       "lenient",
+				Lang:       "tr",
+				CreatedAt:  now,
+			},
+			"",
+		},
+		{
+			"number",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "585",
+					Palindrome: true,
+					Mode:       service.ModeNumeric,
+					Bases:      []int{2, 8, 10},
+					CreatedAt:  now,
+				},
+				nil,
+				nil,
+			},
+			CreateRequest{
+				Number: toStringPointer("585"),
+			},
+			MessageResponse{
+				ID:         "123",
+				Text:       "585",
+				Palindrome: true,
+				Mode:       "numeric",
+				Bases:      []int{2, 8, 10},
 				CreatedAt:  now,
 			},
 			"",
@@ -80,6 +294,94 @@ func TestMakeCreateEndpoint(t *testing.T) {
 			MessageResponse{},
 			ErrBadRequest.Error(),
 		},
+		{
+			"service.ErrInvalidMode",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidMode,
+			},
+			CreateRequest{
+				Text: toStringPointer("racecar"),
+				Mode: toStringPointer("invalid"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"text and number",
+			&mockService{},
+			CreateRequest{
+				Text:   toStringPointer("585"),
+				Number: toStringPointer("585"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"number with another mode",
+			&mockService{},
+			CreateRequest{
+				Number: toStringPointer("585"),
+				Mode:   toStringPointer("strict"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrInvalidNumber",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidNumber,
+			},
+			CreateRequest{
+				Number: toStringPointer("racecar"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrNumberTooLarge",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNumberTooLarge,
+			},
+			CreateRequest{
+				Number: toStringPointer("585"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrInvalidLanguage",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidLanguage,
+			},
+			CreateRequest{
+				Text: toStringPointer("racecar"),
+				Lang: toStringPointer("t"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrInvalidSequence",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidSequence,
+			},
+			CreateRequest{
+				Text: toStringPointer("racecar"),
+				Mode: toStringPointer("dna"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
 		{
 			"unhandled error",
 			&mockService{
@@ -115,12 +417,13 @@ func TestMakeCreateEndpoint(t *testing.T) {
 
 func TestMakeReadEndpoint(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
+	at := time.Now()
 
 	testCases := []struct {
 		name   string
 		svc    service.Service
 		req    ReadRequest
-		want   MessageResponse
+		want   interface{}
 		errMsg string
 	}{
 		{
@@ -146,6 +449,80 @@ func TestMakeReadEndpoint(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"at",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+					Version:    1,
+				},
+				nil,
+				nil,
+			},
+			ReadRequest{
+				ID: "123",
+				At: &at,
+			},
+			MessageAtResponse{
+				MessageResponse{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+					Version:    1,
+				},
+			},
+			"",
+		},
+		{
+			"if none match",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+					Version:    2,
+				},
+				nil,
+				nil,
+			},
+			ReadRequest{
+				ID:          "123",
+				IfNoneMatch: &Condition{Versions: []int{2}},
+			},
+			MessageResponse{},
+			NotModifiedError{2}.Error(),
+		},
+		{
+			"if none match changed",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+					Version:    2,
+				},
+				nil,
+				nil,
+			},
+			ReadRequest{
+				ID:          "123",
+				IfNoneMatch: &Condition{Versions: []int{1}},
+			},
+			MessageResponse{
+				ID:         "123",
+				Text:       "racecar",
+				Palindrome: true,
+				CreatedAt:  now,
+				Version:    2,
+			},
+			"",
+		},
 		{
 			"service.ErrNotFound",
 			&mockService{
@@ -178,41 +555,246 @@ func TestMakeReadEndpoint(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			fn := MakeReadEndpoint(tc.svc)
 			res, err := fn(context.Background(), tc.req)
-			msgRes, ok := res.(MessageResponse)
-			require.True(t, ok)
 			if tc.errMsg == "" {
 				require.NoError(t, err)
-				require.Equal(t, tc.want, msgRes)
+				require.Equal(t, tc.want, res)
 			} else {
 				require.Error(t, err)
 				require.Equal(t, tc.errMsg, err.Error())
-				require.Empty(t, msgRes)
+				require.Equal(t, MessageResponse{}, res)
 			}
 		})
 	}
 }
 
-func TestMakeListEndpoint(t *testing.T) {
+func TestMakeUpdateEndpoint(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 
 	testCases := []struct {
-		name        string
-		listRequest ListRequest
-		svc         service.Service
-		want        []MessageResponse
-		errMsg      string
+		name   string
+		svc    service.Service
+		req    UpdateRequest
+		want   MessageResponse
+		errMsg string
 	}{
 		{
-			"no palindrome query",
-			ListRequest{Palindrome: nil},
+			"success",
 			&mockService{
-				service.Message{},
-				[]service.Message{
-					{
-						ID:         "123",
-						Text:       "racecar",
-						Palindrome: true,
-						CreatedAt:  now,
+				service.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+				},
+				nil,
+				nil,
+			},
+			UpdateRequest{
+				ID:   "123",
+				Text: toStringPointer("racecar"),
+			},
+			MessageResponse{
+				ID:         "123",
+				Text:       "racecar",
+				Palindrome: true,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			},
+			"",
+		},
+		{
+			"partial",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "Racecar",
+					Palindrome: true,
+					Mode:       service.ModeLenient,
+					CreatedAt:  now,
+					UpdatedAt:  now,
+				},
+				nil,
+				nil,
+			},
+			UpdateRequest{
+				ID:      "123",
+				Mode:    toStringPointer("lenient"),
+				Partial: true,
+			},
+			MessageResponse{
+				ID:         "123",
+				Text:       "Racecar",
+				Palindrome: true,
+				Mode:       "lenient",
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			},
+			"",
+		},
+		{
+			"missing text",
+			&mockService{},
+			UpdateRequest{
+				ID:   "123",
+				Mode: toStringPointer("lenient"),
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrNotFound",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			UpdateRequest{
+				ID:      "123",
+				Text:    toStringPointer("racecar"),
+				Partial: true,
+			},
+			MessageResponse{},
+			ErrNotFound.Error(),
+		},
+		{
+			"service.ErrInvalidMode",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidMode,
+			},
+			UpdateRequest{
+				ID:      "123",
+				Mode:    toStringPointer("invalid"),
+				Partial: true,
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			UpdateRequest{
+				ID:   "123",
+				Text: toStringPointer("racecar"),
+			},
+			MessageResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakeUpdateEndpoint(tc.svc)
+			res, err := fn(context.Background(), tc.req)
+			msgRes, ok := res.(MessageResponse)
+			require.True(t, ok)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msgRes)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msgRes)
+			}
+		})
+	}
+}
+
+func TestToUpdatePayload(t *testing.T) {
+	mode := func(m service.Mode) *service.Mode { return &m }
+
+	testCases := []struct {
+		name   string
+		req    UpdateRequest
+		want   service.UpdatePayload
+		errMsg string
+	}{
+		{
+			"full",
+			UpdateRequest{Text: toStringPointer("racecar")},
+			service.UpdatePayload{Text: toStringPointer("racecar"), Mode: mode(""), Lang: toStringPointer("")},
+			"",
+		},
+		{
+			"full number",
+			UpdateRequest{Number: toStringPointer("121")},
+			service.UpdatePayload{Text: toStringPointer("121"), Mode: mode(service.ModeNumeric), Lang: toStringPointer("")},
+			"",
+		},
+		{
+			"full without text",
+			UpdateRequest{Lang: toStringPointer("tr")},
+			service.UpdatePayload{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"partial",
+			UpdateRequest{Mode: toStringPointer("lenient"), Lang: toStringPointer("tr"), Partial: true},
+			service.UpdatePayload{Mode: mode(service.ModeLenient), Lang: toStringPointer("tr")},
+			"",
+		},
+		{
+			"partial number",
+			UpdateRequest{Number: toStringPointer("121"), Partial: true},
+			service.UpdatePayload{Text: toStringPointer("121"), Mode: mode(service.ModeNumeric)},
+			"",
+		},
+		{
+			"partial number with mode",
+			UpdateRequest{Number: toStringPointer("121"), Mode: toStringPointer("strict"), Partial: true},
+			service.UpdatePayload{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"partial text and number",
+			UpdateRequest{Text: toStringPointer("121"), Number: toStringPointer("121"), Partial: true},
+			service.UpdatePayload{},
+			ErrBadRequest.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := toUpdatePayload(tc.req)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, p)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, p)
+			}
+		})
+	}
+}
+
+func TestMakeListEndpoint(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name        string
+		listRequest ListRequest
+		svc         service.Service
+		want        []MessageResponse
+		errMsg      string
+	}{
+		{
+			"no palindrome query",
+			ListRequest{Palindrome: nil},
+			&mockService{
+				service.Message{},
+				[]service.Message{
+					{
+						ID:         "123",
+						Text:       "racecar",
+						Palindrome: true,
+						CreatedAt:  now,
 					},
 					{
 						ID:         "456",
@@ -313,6 +895,76 @@ func TestMakeListEndpoint(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"minLongestPalindrome=7",
+			ListRequest{MinLongestPalindrome: toIntPointer(7)},
+			&mockService{
+				service.Message{},
+				[]service.Message{
+					{
+						ID:         "123",
+						Text:       "racecar",
+						Palindrome: true,
+						LongestPalindrome: service.Substring{
+							Text:   "racecar",
+							Start:  0,
+							End:    7,
+							Length: 7,
+						},
+						CreatedAt: now,
+					},
+				},
+				nil,
+			},
+			[]MessageResponse{
+				{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					LongestPalindrome: SubstringResponse{
+						Text:   "racecar",
+						Start:  0,
+						End:    7,
+						Length: 7,
+					},
+					CreatedAt: now,
+				},
+			},
+			"",
+		},
+		{
+			"service.ErrInvalidBase",
+			ListRequest{Base: toIntPointer(37)},
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidBase,
+			},
+			nil,
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrInvalidSort",
+			ListRequest{Sort: toStringPointer("distance")},
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidSort,
+			},
+			nil,
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrInvalidCursor",
+			ListRequest{Cursor: toStringPointer("invalid")},
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidCursor,
+			},
+			nil,
+			ErrBadRequest.Error(),
+		},
 		{
 			"unhandled error",
 			ListRequest{Palindrome: nil},
@@ -330,15 +982,15 @@ func TestMakeListEndpoint(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			fn := MakeListEndpoint(tc.svc)
 			res, err := fn(context.Background(), tc.listRequest)
-			msgRes, ok := res.([]MessageResponse)
+			listRes, ok := res.(ListResponse)
 			require.True(t, ok)
 			if tc.errMsg == "" {
 				require.NoError(t, err)
-				require.Equal(t, tc.want, msgRes)
+				require.Equal(t, tc.want, listRes.Messages)
 			} else {
 				require.Error(t, err)
 				require.Equal(t, tc.errMsg, err.Error())
-				require.Empty(t, msgRes)
+				require.Empty(t, listRes.Messages)
 			}
 		})
 	}
@@ -370,6 +1022,83 @@ func TestMakeDeleteEndpoint(t *testing.T) {
 			},
 			"",
 		},
+		{
+			"if match",
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			DeleteRequest{
+				ID:      "123",
+				IfMatch: &Condition{Versions: []int{1, 2}},
+			},
+			"",
+		},
+		{
+			"if match any",
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			DeleteRequest{
+				ID:      "123",
+				IfMatch: &Condition{Any: true},
+			},
+			"",
+		},
+		{
+			"if match mismatch",
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			DeleteRequest{
+				ID:      "123",
+				IfMatch: &Condition{Versions: []int{1}},
+			},
+			ErrPreconditionFailed.Error(),
+		},
+		{
+			"if match service.ErrNotFound",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			DeleteRequest{
+				ID:      "123",
+				IfMatch: &Condition{Any: true},
+			},
+			ErrPreconditionFailed.Error(),
+		},
+		{
+			"service.ErrNotFound",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			DeleteRequest{ID: "123"},
+			ErrNotFound.Error(),
+		},
 		{
 			"unhandled error",
 			&mockService{
@@ -396,3 +1125,495 @@ func TestMakeDeleteEndpoint(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeUploadEndpoint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		req    UploadRequest
+		want   UploadResponse
+		errMsg string
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{Palindrome: true},
+				nil,
+				nil,
+			},
+			UploadRequest{
+				Body: strings.NewReader("racecar"),
+				Mode: toStringPointer("strict"),
+			},
+			UploadResponse{
+				Palindrome: true,
+				Mode:       "strict",
+				Size:       7,
+			},
+			"",
+		},
+		{
+			"service.ErrInvalidMode",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidMode,
+			},
+			UploadRequest{
+				Body: strings.NewReader("racecar"),
+				Mode: toStringPointer("word"),
+			},
+			UploadResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			UploadRequest{
+				Body: strings.NewReader("racecar"),
+			},
+			UploadResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakeUploadEndpoint(tc.svc)
+			res, err := fn(context.Background(), tc.req)
+			uploadRes, ok := res.(UploadResponse)
+			require.True(t, ok)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, uploadRes)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, uploadRes)
+			}
+		})
+	}
+}
+
+func TestMakeRevisionsEndpoint(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		want   []RevisionResponse
+		errMsg string
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{},
+				[]service.Message{
+					{ID: "123", Text: "abc", CreatedAt: now, UpdatedAt: now, Version: 2},
+				},
+				nil,
+			},
+			[]RevisionResponse{
+				{
+					MessageResponse{ID: "123", Text: "abc", CreatedAt: now, UpdatedAt: now, Version: 2},
+					now,
+					false,
+				},
+			},
+			"",
+		},
+		{
+			"service.ErrNotFound",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			[]RevisionResponse{},
+			ErrNotFound.Error(),
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			[]RevisionResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakeRevisionsEndpoint(tc.svc)
+			res, err := fn(context.Background(), RevisionsRequest{ID: "123"})
+			revsRes, ok := res.([]RevisionResponse)
+			require.True(t, ok)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+			require.Equal(t, tc.want, revsRes)
+		})
+	}
+}
+
+func TestMakeTrashEndpoint(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		want   []MessageResponse
+		errMsg string
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{},
+				[]service.Message{
+					{ID: "123", Text: "racecar", CreatedAt: now, Version: 1, DeletedAt: now},
+				},
+				nil,
+			},
+			[]MessageResponse{
+				{ID: "123", Text: "racecar", CreatedAt: now, Version: 1, DeletedAt: now},
+			},
+			"",
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			[]MessageResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakeTrashEndpoint(tc.svc)
+			res, err := fn(context.Background(), TrashRequest{})
+			msgsRes, ok := res.([]MessageResponse)
+			require.True(t, ok)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+			require.Equal(t, tc.want, msgsRes)
+		})
+	}
+}
+
+func TestMakeRestoreEndpoint(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		want   MessageResponse
+		errMsg string
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{ID: "123", Text: "racecar", CreatedAt: now, Version: 2},
+				nil,
+				nil,
+			},
+			MessageResponse{ID: "123", Text: "racecar", CreatedAt: now, Version: 2},
+			"",
+		},
+		{
+			"service.ErrNotFound",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			MessageResponse{},
+			ErrNotFound.Error(),
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			MessageResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakeRestoreEndpoint(tc.svc)
+			res, err := fn(context.Background(), RestoreRequest{ID: "123"})
+			msgRes, ok := res.(MessageResponse)
+			require.True(t, ok)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+			require.Equal(t, tc.want, msgRes)
+		})
+	}
+}
+
+func TestMakePalindromesEndpoint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		req    PalindromesRequest
+		want   PalindromesResponse
+		errMsg string
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{
+					LongestPalindrome: service.Substring{
+						Text:   "aba",
+						Start:  1,
+						End:    4,
+						Length: 3,
+					},
+				},
+				nil,
+				nil,
+			},
+			PalindromesRequest{
+				ID:        "123",
+				MinLength: toIntPointer(2),
+				Offset:    toIntPointer(0),
+				Limit:     toIntPointer(10),
+			},
+			PalindromesResponse{
+				Palindromes: []PalindromicSubstringResponse{
+					{
+						Text:   "aba",
+						Length: 3,
+						Count:  1,
+						Start:  1,
+					},
+				},
+				Total: 1,
+			},
+			"",
+		},
+		{
+			"service.ErrNotFound",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			PalindromesRequest{ID: "123"},
+			PalindromesResponse{},
+			ErrNotFound.Error(),
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			PalindromesRequest{ID: "123"},
+			PalindromesResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakePalindromesEndpoint(tc.svc)
+			res, err := fn(context.Background(), tc.req)
+			palindromesRes, ok := res.(PalindromesResponse)
+			require.True(t, ok)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, palindromesRes)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, palindromesRes)
+			}
+		})
+	}
+}
+
+func TestMakeCheckEndpoint(t *testing.T) {
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		req    CheckRequest
+		want   interface{}
+		errMsg string
+	}{
+		{
+			"single",
+			&mockService{
+				service.Message{
+					Text:       "racecar",
+					Palindrome: true,
+					Mode:       service.ModeStrict,
+				},
+				nil,
+				nil,
+			},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar")},
+				},
+				Single: true,
+			},
+			MessageResponse{
+				Text:       "racecar",
+				Palindrome: true,
+				Mode:       "strict",
+			},
+			"",
+		},
+		{
+			"array",
+			&mockService{
+				service.Message{
+					Text:       "racecar",
+					Palindrome: true,
+					Mode:       service.ModeStrict,
+				},
+				nil,
+				nil,
+			},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar")},
+					{Text: toStringPointer("racecar")},
+				},
+			},
+			[]MessageResponse{
+				{
+					Text:       "racecar",
+					Palindrome: true,
+					Mode:       "strict",
+				},
+				{
+					Text:       "racecar",
+					Palindrome: true,
+					Mode:       "strict",
+				},
+			},
+			"",
+		},
+		{
+			"empty array",
+			&mockService{},
+			CheckRequest{},
+			[]MessageResponse{},
+			"",
+		},
+		{
+			"ErrBadRequest",
+			&mockService{},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar")},
+					{},
+				},
+			},
+			[]MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrInvalidMode",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidMode,
+			},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar"), Mode: toStringPointer("invalid")},
+				},
+				Single: true,
+			},
+			MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrTooManyPayloads",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrTooManyPayloads,
+			},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar")},
+				},
+			},
+			[]MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"service.ErrTooManyRunes",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrTooManyRunes,
+			},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar")},
+				},
+			},
+			[]MessageResponse{},
+			ErrBadRequest.Error(),
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			CheckRequest{
+				Payloads: []CreateRequest{
+					{Text: toStringPointer("racecar")},
+				},
+			},
+			[]MessageResponse{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := MakeCheckEndpoint(tc.svc)
+			res, err := fn(context.Background(), tc.req)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+			require.Equal(t, tc.want, res)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+// Package idgen implements generators of Message IDs.
+package idgen
+
+import (
+	"errors"
+
+	"github.com/mongodb/mongo-go-driver/bson/objectid"
+	"github.com/satori/go.uuid"
+)
+
+// ErrInvalidScheme is returned if no Generator implements a scheme.
+var ErrInvalidScheme = errors.New("invalid ID scheme")
+
+// Schemes of the Generators returned by Parse.
+const (
+	SchemeUUID     = "uuid"
+	SchemeObjectID = "objectid"
+	SchemeULID     = "ulid"
+)
+
+// Generator generates the IDs of Messages, and validates that an ID could have been generated by it.
+type Generator interface {
+	New() string
+	Valid(id string) bool
+}
+
+// Parse returns a new Generator that implements scheme.
+func Parse(scheme string) (Generator, error) {
+	switch scheme {
+	case SchemeUUID:
+		return NewUUID(), nil
+	case SchemeObjectID:
+		return NewObjectID(), nil
+	case SchemeULID:
+		return NewULID(), nil
+	}
+	return nil, ErrInvalidScheme
+}
+
+type acceptingGenerator struct {
+	Generator
+	accepted []Generator
+}
+
+// Accept returns a Generator that generates IDs like g, and also validates IDs that could have been generated by one of accepted, so Messages created before the scheme was changed can still be read.
+func Accept(g Generator, accepted ...Generator) Generator {
+	if len(accepted) == 0 {
+		return g
+	}
+	return acceptingGenerator{g, accepted}
+}
+
+func (g acceptingGenerator) Valid(id string) bool {
+	if g.Generator.Valid(id) {
+		return true
+	}
+	for _, a := range g.accepted {
+		if a.Valid(id) {
+			return true
+		}
+	}
+	return false
+}
+
+type uuidGenerator struct{}
+
+// NewUUID returns a Generator of random UUIDs in their canonical form, like "6ba7b810-9dad-41d1-80b4-00c04fd430c8".
+func NewUUID() Generator {
+	return uuidGenerator{}
+}
+
+func (uuidGenerator) New() string {
+	return uuid.NewV4().String()
+}
+
+func (uuidGenerator) Valid(id string) bool {
+	u, err := uuid.FromString(id)
+	return err == nil && u.String() == id
+}
+
+type objectIDGenerator struct{}
+
+// NewObjectID returns a Generator of MongoDB ObjectIDs in lower case hexadecimal, like "5b1a2b3c4d5e6f7a8b9c0d1e".
+func NewObjectID() Generator {
+	return objectIDGenerator{}
+}
+
+func (objectIDGenerator) New() string {
+	return objectid.New().Hex()
+}
+
+func (objectIDGenerator) Valid(id string) bool {
+	oid, err := objectid.FromHex(id)
+	return err == nil && oid.Hex() == id
+}
@@ -0,0 +1,242 @@
+package idgen
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name   string
+		scheme string
+		want   Generator
+		errMsg string
+	}{
+		{
+			"uuid",
+			SchemeUUID,
+			uuidGenerator{},
+			"",
+		},
+		{
+			"objectid",
+			SchemeObjectID,
+			objectIDGenerator{},
+			"",
+		},
+		{
+			"unknown scheme",
+			"ksuid",
+			nil,
+			ErrInvalidScheme.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := Parse(tc.scheme)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, g)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, g)
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	testCases := []struct {
+		name string
+		g    Generator
+		id   string
+		want bool
+	}{
+		{
+			"uuid",
+			NewUUID(),
+			"6ba7b810-9dad-41d1-80b4-00c04fd430c8",
+			true,
+		},
+		{
+			"upper case uuid",
+			NewUUID(),
+			"6BA7B810-9DAD-41D1-80B4-00C04FD430C8",
+			false,
+		},
+		{
+			"uuid without hyphens",
+			NewUUID(),
+			"6ba7b8109dad41d180b400c04fd430c8",
+			false,
+		},
+		{
+			"objectid",
+			NewObjectID(),
+			"5b1a2b3c4d5e6f7a8b9c0d1e",
+			true,
+		},
+		{
+			"short objectid",
+			NewObjectID(),
+			"5b1a2b3c4d5e6f7a8b9c0d",
+			false,
+		},
+		{
+			"ulid",
+			NewULID(),
+			"01BX5ZZKBKACTAV9WEVGEMMVRZ",
+			true,
+		},
+		{
+			"ulid with excluded letter",
+			NewULID(),
+			"01BX5ZZKBKACTAV9WEVGEMMVRU",
+			false,
+		},
+		{
+			"overflowing ulid",
+			NewULID(),
+			"81BX5ZZKBKACTAV9WEVGEMMVRZ",
+			false,
+		},
+		{
+			"short ulid",
+			NewULID(),
+			"01BX5ZZKBKACTAV9WEVGEMMVR",
+			false,
+		},
+		{
+			"uuid as ulid",
+			NewULID(),
+			"6ba7b810-9dad-41d1-80b4-00c04fd430c8",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.g.Valid(tc.id))
+		})
+	}
+}
+
+func TestAccept(t *testing.T) {
+	testCases := []struct {
+		name string
+		id   string
+		want bool
+	}{
+		{
+			"active scheme",
+			"01BX5ZZKBKACTAV9WEVGEMMVRZ",
+			true,
+		},
+		{
+			"accepted scheme",
+			"6ba7b810-9dad-41d1-80b4-00c04fd430c8",
+			true,
+		},
+		{
+			"scheme not accepted",
+			"5b1a2b3c4d5e6f7a8b9c0d1e",
+			false,
+		},
+		{
+			"invalid",
+			"123",
+			false,
+		},
+	}
+
+	g := Accept(NewULID(), NewUUID())
+	require.True(t, NewULID().Valid(g.New()))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, g.Valid(tc.id))
+		})
+	}
+	ulid := NewULID()
+	require.True(t, ulid == Accept(ulid))
+}
+
+func TestNewValid(t *testing.T) {
+	for _, g := range []Generator{NewUUID(), NewObjectID(), NewULID()} {
+		id := g.New()
+		require.True(t, g.Valid(id), id)
+		require.NotEqual(t, id, g.New())
+	}
+}
+
+func TestEncode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ms      uint64
+		entropy [10]byte
+		want    string
+	}{
+		{
+			"zero",
+			0,
+			[10]byte{},
+			"00000000000000000000000000",
+		},
+		{
+			"max",
+			1<<48 - 1,
+			[10]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+			"7ZZZZZZZZZZZZZZZZZZZZZZZZZ",
+		},
+		{
+			"time only",
+			1469918176385,
+			[10]byte{},
+			"01ARYZ6S41" + "0000000000000000",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, encode(tc.ms, tc.entropy))
+		})
+	}
+}
+
+func TestULIDMonotonic(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := []time.Time{now, now, now.Add(-time.Second), now.Add(time.Millisecond)}
+	g := newULID(func() time.Time {
+		t := clock[0]
+		clock = clock[1:]
+		return t
+	}, bytes.NewReader(bytes.Repeat([]byte{0x01}, 20)))
+
+	ids := make([]string, len(clock))
+	for i := range ids {
+		ids[i] = g.New()
+	}
+	require.True(t, sort.StringsAreSorted(ids), ids)
+	// The time of the last ULID is kept within the same millisecond and if the clock went backwards.
+	require.Equal(t, ids[0][:10], ids[1][:10])
+	require.Equal(t, ids[0][:10], ids[2][:10])
+	require.NotEqual(t, ids[0][:10], ids[3][:10])
+}
+
+func TestULIDOverflow(t *testing.T) {
+	now := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	g := newULID(func() time.Time {
+		return now
+	}, bytes.NewReader(bytes.Repeat([]byte{0xff}, 20)))
+
+	first := g.New()
+	second := g.New()
+	require.True(t, first < second)
+	require.Equal(t, "ZZZZZZZZZZZZZZZZ", first[10:])
+	require.Equal(t, encode(uint64(now.UnixNano()/int64(time.Millisecond))+1, [10]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}), second)
+}
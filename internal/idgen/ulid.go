@@ -0,0 +1,105 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"sync"
+	"time"
+)
+
+// crockford is the Crockford base32 alphabet ULIDs are encoded with.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulidLength is the number of characters of an encoded ULID.
+const ulidLength = 26
+
+type ulidGenerator struct {
+	now  func() time.Time
+	rand io.Reader
+	// mu guards ms and entropy, which are the time and random bits of the last ULID.
+	mu      sync.Mutex
+	ms      uint64
+	entropy [10]byte
+}
+
+// NewULID returns a Generator of ULIDs, like "01BX5ZZKBKACTAV9WEVGEMMVRZ", which sort like the times they were generated at.
+// ULIDs generated within the same millisecond are monotonic, because the random bits of the last ULID are incremented instead of generated.
+func NewULID() Generator {
+	return newULID(time.Now, rand.Reader)
+}
+
+func newULID(now func() time.Time, rand io.Reader) *ulidGenerator {
+	return &ulidGenerator{
+		now:  now,
+		rand: rand,
+	}
+}
+
+func (g *ulidGenerator) New() string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	ms := uint64(g.now().UnixNano() / int64(time.Millisecond))
+	if ms <= g.ms {
+		// Within the millisecond of the last ULID, or if the clock went backwards, the random bits of the last ULID are incremented.
+		ms = g.ms
+		if increment(g.entropy[:]) {
+			return encode(ms, g.entropy)
+		}
+		// The random bits overflowed, so the ULID is moved to the next millisecond.
+		ms++
+	}
+	if _, err := io.ReadFull(g.rand, g.entropy[:]); err != nil {
+		panic(err)
+	}
+	g.ms = ms
+	return encode(ms, g.entropy)
+}
+
+// Valid returns true if id is a ULID in its canonical upper case form.
+func (g *ulidGenerator) Valid(id string) bool {
+	if len(id) != ulidLength || id[0] > '7' {
+		// The first character encodes only the 3 highest bits of the time.
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if !containsByte(crockford, id[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// increment adds one to the big-endian number b, and returns false if it overflowed.
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encode returns the 48 bits of ms followed by the 80 bits of entropy in Crockford base32.
+func encode(ms uint64, entropy [10]byte) string {
+	hi := ms<<16 | uint64(binary.BigEndian.Uint16(entropy[:2]))
+	lo := binary.BigEndian.Uint64(entropy[2:])
+	var s [ulidLength]byte
+	// 26 characters of 5 bits encode 130 bits, so the first character encodes the 3 remaining bits.
+	for i := ulidLength - 1; i >= 0; i-- {
+		s[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(s[:])
+}
+
+func containsByte(s string, c byte) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] == c {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,9 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
+	"time"
+	"unicode/utf8"
 
 	"github.com/nicholaslam/example-service/internal/store"
 	"github.com/nicholaslam/example-service/pkg/palindrome"
@@ -12,57 +15,284 @@ import (
 var (
 	// ErrNotFound is returned if a Message is not found.
 	ErrNotFound = errors.New("not found")
+
+	// ErrInvalidMode is returned if no palindrome.Checker is registered for a Mode.
+	ErrInvalidMode = errors.New("invalid mode")
+
+	// ErrInvalidSequence is returned if a Message evaluated with ModeDNA is not a nucleotide sequence.
+	ErrInvalidSequence = errors.New("invalid sequence")
+
+	// ErrInvalidNumber is returned if a Message evaluated with ModeNumeric is not a non-negative decimal integer.
+	ErrInvalidNumber = errors.New("invalid number")
+
+	// ErrNumberTooLarge is returned if a Message evaluated with ModeNumeric has more than MaxNumberDigits digits.
+	ErrNumberTooLarge = errors.New("number too large")
+
+	// ErrInvalidBase is returned if Messages are listed by a base that is not between palindrome.MinBase and palindrome.MaxBase.
+	ErrInvalidBase = errors.New("invalid base")
+
+	// ErrTooManyPayloads is returned if more than MaxCheckPayloads are checked at once.
+	ErrTooManyPayloads = errors.New("too many payloads")
+
+	// ErrTooManyRunes is returned if the texts checked at once have more than MaxCheckRunes runes in total.
+	ErrTooManyRunes = errors.New("too many runes")
+
+	// ErrVersionMismatch is returned if a conditional operation expects a different Version of a Message.
+	ErrVersionMismatch = errors.New("version mismatch")
+
+	// ErrInvalidLanguage is returned if a language is not a well-formed BCP 47 tag, or is used with a Mode other than ModeLenient.
+	ErrInvalidLanguage = errors.New("invalid language")
+
+	// ErrInvalidSort is returned if Messages are listed by a field other than SortCreatedAt, SortLength, and SortText.
+	ErrInvalidSort = errors.New("invalid sort")
+
+	// ErrInvalidCursor is returned if Messages are listed after a cursor that is malformed or was returned for a different sort order.
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Limits of a PalindromesPayload.
+const (
+	DefaultPalindromesLimit = 100
+	MaxPalindromesLimit     = 1000
+)
+
+// Limits of a ListPayload.
+const (
+	DefaultListLimit = 100
+	MaxListLimit     = 1000
+)
+
+// Sort is the field Messages are listed by. Messages with equal fields are listed by ID.
+type Sort string
+
+// Fields Messages can be listed by.
+const (
+	SortCreatedAt Sort = "createdAt"
+	SortLength    Sort = "length"
+	SortText      Sort = "text"
+)
+
+// Limits of the MessagePayloads checked at once. The distance of a text takes quadratic time, so the runes of all texts are limited in addition to their number.
+const (
+	MaxCheckPayloads = 1000
+	MaxCheckRunes    = 8 * palindrome.MaxDistanceLength
+)
+
+// MaxNumberDigits is the maximum length of a Message evaluated with ModeNumeric. Its digits are converted to every base between palindrome.MinBase and palindrome.MaxBase, which takes quadratic time.
+const MaxNumberDigits = palindrome.MaxDistanceLength
+
+// Mode is the name of the palindrome.Checker a Service uses to evaluate whether a Message is a palindrome.
+type Mode string
+
+// Modes of the Checkers registered by palindrome.NewDefaultRegistry.
+const (
+	ModeStrict   Mode = palindrome.Strict
+	ModeLenient  Mode = palindrome.Lenient
+	ModeUnicode  Mode = palindrome.Unicode
+	ModeGrapheme Mode = palindrome.Grapheme
+	ModeWord     Mode = palindrome.Word
+	ModeLine     Mode = palindrome.Line
+	ModeDNA      Mode = palindrome.DNA
+	ModeNumeric  Mode = palindrome.Numeric
+	ModeMirror   Mode = palindrome.Mirror
+	ModeRotation Mode = palindrome.Rotation
 )
 
+// Config represents the configuration of a Service.
+type Config struct {
+	// Checkers are the palindrome.Checkers a Message can be evaluated with. It defaults to palindrome.NewDefaultRegistry.
+	Checkers *palindrome.Registry
+	// Mode is used if a MessagePayload does not specify a Mode. It defaults to ModeStrict.
+	Mode Mode
+	// DistanceSubstitutions counts replacing a rune as a single edit when computing the distance of a Message to a palindrome.
+	DistanceSubstitutions bool
+	// MinSiteLength is the minimum length of the Sites of a Message evaluated with ModeDNA. It defaults to palindrome.DefaultMinSiteLength.
+	MinSiteLength int
+	// TrashRetention is how long deleted Messages are kept in the trash before EmptyTrash purges them. If it is zero, they are kept until they are purged explicitly.
+	TrashRetention time.Duration
+}
+
 // Service describes a service that stores Messages.
 type Service interface {
 	Create(ctx context.Context, p MessagePayload) (Message, error)
+	Check(ctx context.Context, ps []MessagePayload) ([]Message, error)
 	Read(ctx context.Context, id string) (Message, error)
-	List(ctx context.Context, p ListPayload) ([]Message, error)
-	Delete(ctx context.Context, id string) error
+	ReadAt(ctx context.Context, id string, at time.Time) (Message, error)
+	Revisions(ctx context.Context, id string) ([]Revision, error)
+	Update(ctx context.Context, id string, p UpdatePayload) (Message, error)
+	List(ctx context.Context, p ListPayload) (MessagesPage, error)
+	Delete(ctx context.Context, id string, p DeletePayload) error
+	Trash(ctx context.Context) ([]Message, error)
+	Restore(ctx context.Context, id string) (Message, error)
+	EmptyTrash(ctx context.Context) (int, error)
+	CheckStream(ctx context.Context, r io.Reader, mode Mode) (StreamResult, error)
+	Palindromes(ctx context.Context, id string, p PalindromesPayload) (PalindromesPage, error)
 }
 
 // MessagePayload represents a payload used to create a Message.
 type MessagePayload struct {
 	Text string
+	Mode Mode
+	// Lang is a BCP 47 language tag that selects the case folding and equivalence rules of ModeLenient.
+	Lang string
+}
+
+// UpdatePayload represents a payload used to update a Message. Nil fields are left unchanged.
+type UpdatePayload struct {
+	Text *string
+	Mode *Mode
+	Lang *string
+}
+
+// DeletePayload represents a payload used to delete a Message.
+type DeletePayload struct {
+	// Version is nil if the Message is deleted regardless of its Version.
+	Version *int
+	// Purge permanently deletes the Message and its history instead of moving it to the trash.
+	Purge bool
 }
 
 // ListPayload represents a payload used to list Messages.
 type ListPayload struct {
-	Palindrome *bool
+	Palindrome           *bool
+	MinLongestPalindrome *int
+	MaxDistance          *int
+	Rearrangeable        *bool
+	// Base lists the Messages whose number is a palindrome in Base.
+	Base *int
+	// CreatedAfter and CreatedBefore list the Messages created strictly between them.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Prefix and Contains are matched against Text, and are case-sensitive.
+	Prefix   *string
+	Contains *string
+	// MinLength and MaxLength bound the number of runes of Text.
+	MinLength *int
+	MaxLength *int
+	// Sort defaults to SortCreatedAt.
+	Sort       Sort
+	Descending bool
+	// Limit defaults to DefaultListLimit, and is at most MaxListLimit.
+	Limit int
+	// Cursor is the Next cursor of the previous MessagesPage, or empty for the first page.
+	Cursor string
+}
+
+// PalindromesPayload represents a payload used to list the palindromic substrings of a Message.
+type PalindromesPayload struct {
+	MinLength int
+	Offset    int
+	// Limit defaults to DefaultPalindromesLimit, and is at most MaxPalindromesLimit.
+	Limit int
 }
 
 // Message represents a string that may be a palindrome.
 type Message struct {
-	ID         string
-	Text       string
+	ID                string
+	Text              string
+	Length            int
+	Palindrome        bool
+	Mode              Mode
+	Lang              string
+	LongestPalindrome Substring
+	// Distance is the minimum number of edits of the units compared by Mode needed to make Text a palindrome, and Suggestion is one such palindrome made of those units.
+	// Distance is nil if Text has more than palindrome.MaxDistanceLength units, or if the palindrome.Checker of Mode is not a palindrome.Repairer.
+	Distance   *int
+	Suggestion string
+	// Rearrangeable is true if the characters of Text can be rearranged into a palindrome, and Arrangement is one such palindrome.
+	Rearrangeable bool
+	Arrangement   string
+	// Sites are the reverse complement palindromic sites of Text if it was evaluated with ModeDNA.
+	Sites []Site
+	// Bases are the bases in which Text is a palindrome if it was evaluated with ModeNumeric.
+	Bases     []int
+	CreatedAt string
+	// UpdatedAt is empty if the Message was never updated.
+	UpdatedAt string
+	// Version is incremented each time the Message is updated or restored.
+	Version int
+	// DeletedAt is empty unless the Message is in the trash.
+	DeletedAt string
+}
+
+// Revision represents a Message as it was from RevisedAt until the next Revision.
+type Revision struct {
+	Message Message
+	// RevisedAt is the time the Message was created, updated, or deleted.
+	RevisedAt string
+	// Deleted is true if the Message was deleted at RevisedAt.
+	Deleted bool
+}
+
+// MessagesPage represents a page of Messages.
+type MessagesPage struct {
+	Messages []Message
+	// Next is the cursor of the next page, or empty if this is the last page.
+	Next string
+}
+
+// StreamResult represents the evaluation of a text that is not stored.
+type StreamResult struct {
 	Palindrome bool
-	CreatedAt  string
+	Mode       Mode
+	// Size is the number of bytes read.
+	Size int64
+}
+
+// PalindromesPage represents a page of the distinct palindromic substrings of a Message, longest first.
+type PalindromesPage struct {
+	Palindromes []PalindromicSubstring
+	// Total is the number of palindromic substrings on all pages.
+	Total int
+}
+
+// PalindromicSubstring represents a distinct palindromic substring of a Message and its occurrences. Offsets are measured in runes.
+type PalindromicSubstring struct {
+	Text   string
+	Length int
+	Count  int
+	Start  int
+}
+
+// Substring represents a palindromic substring of a Message. Offsets are measured in runes.
+type Substring struct {
+	Text   string
+	Start  int
+	End    int
+	Length int
+}
+
+// Site represents a reverse complement palindromic site of a Message. Offsets are measured in nucleotides.
+type Site struct {
+	Sequence string
+	Start    int
+	End      int
+	Length   int
 }
 
 type basicService struct {
-	store            store.Store
-	strictPalindrome bool
+	store store.Store
+	cfg   Config
 }
 
 // NewService returns a new service.
-func NewService(s store.Store, strict bool) Service {
+func NewService(s store.Store, cfg Config) Service {
+	if cfg.Checkers == nil {
+		cfg.Checkers = palindrome.NewDefaultRegistry(palindrome.UnicodeOptions{})
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = ModeStrict
+	}
 	return &basicService{
-		store:            s,
-		strictPalindrome: strict,
+		store: s,
+		cfg:   cfg,
 	}
 }
 
 func (s *basicService) Create(ctx context.Context, p MessagePayload) (Message, error) {
-	var pal bool
-	if s.strictPalindrome {
-		pal = palindrome.IsPalindromeStrict(p.Text)
-	} else {
-		pal = palindrome.IsPalindrome(p.Text)
-	}
-	payload := store.MessagePayload{
-		Text:       p.Text,
-		Palindrome: pal,
+	payload, err := s.evaluate(p)
+	if err != nil {
+		return Message{}, err
 	}
 	msg, err := s.store.Create(ctx, payload)
 	if err != nil {
@@ -71,6 +301,45 @@ func (s *basicService) Create(ctx context.Context, p MessagePayload) (Message, e
 	return toMessage(msg), nil
 }
 
+// Check evaluates each MessagePayload like Create without storing it. The returned Messages have no ID or CreatedAt.
+func (s *basicService) Check(ctx context.Context, ps []MessagePayload) ([]Message, error) {
+	if len(ps) > MaxCheckPayloads {
+		return []Message{}, ErrTooManyPayloads
+	}
+	runes := 0
+	for _, p := range ps {
+		runes += utf8.RuneCountInString(p.Text)
+		if runes > MaxCheckRunes {
+			return []Message{}, ErrTooManyRunes
+		}
+	}
+	msgs := make([]Message, 0, len(ps))
+	for _, p := range ps {
+		if err := ctx.Err(); err != nil {
+			return []Message{}, err
+		}
+		payload, err := s.evaluate(p)
+		if err != nil {
+			return []Message{}, err
+		}
+		msgs = append(msgs, toMessage(store.Message{
+			Text:              payload.Text,
+			Length:            payload.Length,
+			Palindrome:        payload.Palindrome,
+			Mode:              payload.Mode,
+			Lang:              payload.Lang,
+			LongestPalindrome: payload.LongestPalindrome,
+			Distance:          payload.Distance,
+			Suggestion:        payload.Suggestion,
+			Rearrangeable:     payload.Rearrangeable,
+			Arrangement:       payload.Arrangement,
+			Sites:             payload.Sites,
+			Bases:             payload.Bases,
+		}))
+	}
+	return msgs, nil
+}
+
 func (s *basicService) Read(ctx context.Context, id string) (Message, error) {
 	msg, err := s.store.Read(ctx, id)
 	if err != nil {
@@ -82,34 +351,390 @@ func (s *basicService) Read(ctx context.Context, id string) (Message, error) {
 	return toMessage(msg), nil
 }
 
-func (s *basicService) List(ctx context.Context, p ListPayload) ([]Message, error) {
+// ReadAt reads the Message with id as it was at a point in time, even if it was deleted since.
+func (s *basicService) ReadAt(ctx context.Context, id string, at time.Time) (Message, error) {
+	msg, err := s.store.ReadAt(ctx, id, at)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return Message{}, ErrNotFound
+		}
+		return Message{}, err
+	}
+	return toMessage(msg), nil
+}
+
+// Revisions returns the history of the Message with id, oldest first, unless it was purged.
+func (s *basicService) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	revs, err := s.store.Revisions(ctx, id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return []Revision{}, ErrNotFound
+		}
+		return []Revision{}, err
+	}
+	res := []Revision{}
+	for _, rev := range revs {
+		res = append(res, Revision{
+			Message:   toMessage(rev.Message),
+			RevisedAt: formatTime(rev.RevisedAt),
+			Deleted:   rev.Deleted,
+		})
+	}
+	return res, nil
+}
+
+// Update merges p into the Message with id and evaluates it again.
+// If the Mode is changed to one other than ModeLenient and p does not set Lang, the language of the Message is dropped.
+// The Message is only replaced if it was not changed since it was read, and is merged again otherwise, so concurrent updates are not lost.
+func (s *basicService) Update(ctx context.Context, id string, p UpdatePayload) (Message, error) {
+	for {
+		if err := ctx.Err(); err != nil {
+			return Message{}, err
+		}
+		msg, err := s.update(ctx, id, p)
+		if err == store.ErrVersionMismatch {
+			continue
+		}
+		return msg, err
+	}
+}
+
+// update merges p into the Message with id once, and returns store.ErrVersionMismatch if it was changed in the meantime.
+func (s *basicService) update(ctx context.Context, id string, p UpdatePayload) (Message, error) {
+	old, err := s.store.Read(ctx, id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return Message{}, ErrNotFound
+		}
+		return Message{}, err
+	}
+	merged := MessagePayload{
+		Text: old.Text,
+		Mode: Mode(old.Mode),
+		Lang: old.Lang,
+	}
+	if p.Text != nil {
+		merged.Text = *p.Text
+	}
+	if p.Mode != nil {
+		merged.Mode = *p.Mode
+		if p.Lang == nil && merged.Mode != ModeLenient {
+			merged.Lang = ""
+		}
+	}
+	if p.Lang != nil {
+		merged.Lang = *p.Lang
+	}
+	payload, err := s.evaluate(merged)
+	if err != nil {
+		return Message{}, err
+	}
+	msg, err := s.store.Update(ctx, id, payload, &old.Version)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return Message{}, ErrNotFound
+		}
+		return Message{}, err
+	}
+	return toMessage(msg), nil
+}
+
+func (s *basicService) List(ctx context.Context, p ListPayload) (MessagesPage, error) {
+	if p.Base != nil && (*p.Base < palindrome.MinBase || *p.Base > palindrome.MaxBase) {
+		return MessagesPage{}, ErrInvalidBase
+	}
+	switch p.Sort {
+	case "", SortCreatedAt, SortLength, SortText:
+	default:
+		return MessagesPage{}, ErrInvalidSort
+	}
+	limit := p.Limit
+	if limit == 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
 	payload := store.ListPayload{
-		Palindrome: p.Palindrome,
+		Palindrome:           p.Palindrome,
+		MinLongestPalindrome: p.MinLongestPalindrome,
+		MaxDistance:          p.MaxDistance,
+		Rearrangeable:        p.Rearrangeable,
+		Base:                 p.Base,
+		CreatedAfter:         p.CreatedAfter,
+		CreatedBefore:        p.CreatedBefore,
+		Prefix:               p.Prefix,
+		Contains:             p.Contains,
+		MinLength:            p.MinLength,
+		MaxLength:            p.MaxLength,
+		Sort:                 store.Sort(p.Sort),
+		Descending:           p.Descending,
+		Limit:                limit,
+		Cursor:               p.Cursor,
 	}
-	msgs, err := s.store.List(ctx, payload)
+	page, err := s.store.List(ctx, payload)
 	if err != nil {
-		return []Message{}, err
+		if err == store.ErrInvalidCursor {
+			return MessagesPage{}, ErrInvalidCursor
+		}
+		return MessagesPage{}, err
 	}
-	return toSlice(msgs), nil
+	return MessagesPage{
+		Messages: toSlice(page.Messages),
+		Next:     page.Next,
+	}, nil
 }
 
-func (s *basicService) Delete(ctx context.Context, id string) error {
-	err := s.store.Delete(ctx, id)
-	if err == store.ErrNotFound {
+// Delete moves the Message with id to the trash, and returns ErrNotFound if it does not exist. If p.Version is not nil, the Message is only deleted if it has that Version, and ErrVersionMismatch is returned otherwise, including if it does not exist.
+// If p.Purge is true, the Message and its history are deleted permanently, and a Message that is already in the trash can also be purged.
+func (s *basicService) Delete(ctx context.Context, id string, p DeletePayload) error {
+	err := s.store.Delete(ctx, id, p.Version)
+	switch err {
+	case nil:
+	case store.ErrNotFound:
+		if p.Version != nil {
+			return ErrVersionMismatch
+		}
+		if !p.Purge {
+			return ErrNotFound
+		}
+	case store.ErrVersionMismatch:
+		return ErrVersionMismatch
+	default:
+		return err
+	}
+	if !p.Purge {
 		return nil
 	}
+	err = s.store.Purge(ctx, id)
+	if err == store.ErrNotFound {
+		return ErrNotFound
+	}
 	return err
 }
 
+// Trash lists the Messages that were deleted and have not been purged yet.
+func (s *basicService) Trash(ctx context.Context) ([]Message, error) {
+	msgs, err := s.store.Trash(ctx)
+	if err != nil {
+		return []Message{}, err
+	}
+	return toSlice(msgs), nil
+}
+
+// Restore moves the Message with id out of the trash.
+func (s *basicService) Restore(ctx context.Context, id string) (Message, error) {
+	msg, err := s.store.Restore(ctx, id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return Message{}, ErrNotFound
+		}
+		return Message{}, err
+	}
+	return toMessage(msg), nil
+}
+
+// EmptyTrash permanently deletes the Messages that have been in the trash for longer than the configured TrashRetention and their revisions, and returns how many were deleted.
+func (s *basicService) EmptyTrash(ctx context.Context) (int, error) {
+	if s.cfg.TrashRetention == 0 {
+		return 0, nil
+	}
+	return s.store.EmptyTrash(ctx, time.Now().Add(-s.cfg.TrashRetention))
+}
+
+// CheckStream evaluates the text read from r without storing it or holding it in memory.
+// Only ModeStrict and ModeLenient are supported. If mode is empty, the configured Mode is used.
+func (s *basicService) CheckStream(ctx context.Context, r io.Reader, mode Mode) (StreamResult, error) {
+	if mode == "" {
+		mode = s.cfg.Mode
+	}
+	if mode != ModeStrict && mode != ModeLenient {
+		return StreamResult{}, ErrInvalidMode
+	}
+	cr := &countingReader{ctx: ctx, r: r}
+	pal, err := palindrome.IsPalindromeReader(cr, mode == ModeStrict)
+	if err != nil {
+		return StreamResult{}, err
+	}
+	return StreamResult{
+		Palindrome: pal,
+		Mode:       mode,
+		Size:       cr.n,
+	}, nil
+}
+
+// Palindromes lists the distinct palindromic substrings of the Message with id that are at least p.MinLength runes long.
+func (s *basicService) Palindromes(ctx context.Context, id string, p PalindromesPayload) (PalindromesPage, error) {
+	msg, err := s.store.Read(ctx, id)
+	if err != nil {
+		if err == store.ErrNotFound {
+			return PalindromesPage{}, ErrNotFound
+		}
+		return PalindromesPage{}, err
+	}
+	limit := p.Limit
+	if limit == 0 {
+		limit = DefaultPalindromesLimit
+	}
+	if limit > MaxPalindromesLimit {
+		limit = MaxPalindromesLimit
+	}
+	subs := palindrome.PalindromicSubstrings(msg.Text, p.MinLength)
+	page := PalindromesPage{
+		Palindromes: []PalindromicSubstring{},
+		Total:       len(subs),
+	}
+	for i := p.Offset; i < len(subs) && i < p.Offset+limit; i++ {
+		page.Palindromes = append(page.Palindromes, PalindromicSubstring{
+			Text:   subs[i].Text,
+			Length: subs[i].Length,
+			Count:  subs[i].Count,
+			Start:  subs[i].Start,
+		})
+	}
+	return page, nil
+}
+
+// countingReader counts the bytes read from r, and stops reading once ctx is done.
+type countingReader struct {
+	ctx context.Context
+	r   io.Reader
+	n   int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+// evaluate analyzes the Text of p without storing it.
+func (s *basicService) evaluate(p MessagePayload) (store.MessagePayload, error) {
+	mode := s.cfg.Mode
+	if p.Mode != "" {
+		mode = p.Mode
+	}
+	checker, ok := s.cfg.Checkers.Lookup(string(mode))
+	if !ok {
+		return store.MessagePayload{}, ErrInvalidMode
+	}
+	var locale palindrome.Locale
+	if p.Lang != "" {
+		if mode != ModeLenient {
+			return store.MessagePayload{}, ErrInvalidLanguage
+		}
+		var err error
+		locale, err = palindrome.ParseLocale(p.Lang)
+		if err != nil {
+			return store.MessagePayload{}, ErrInvalidLanguage
+		}
+		checker = palindrome.LenientChecker(locale)
+	}
+	longest := palindrome.LongestSubstring(p.Text)
+	payload := store.MessagePayload{
+		Text:       p.Text,
+		Length:     utf8.RuneCountInString(p.Text),
+		Palindrome: checker.IsPalindrome(p.Text),
+		Mode:       string(mode),
+		Lang:       locale.String(),
+		LongestPalindrome: store.Substring{
+			Text:   longest.Text,
+			Start:  longest.Start,
+			End:    longest.End,
+			Length: longest.Length,
+		},
+	}
+	payload.Arrangement, payload.Rearrangeable = palindrome.Rearrange(p.Text)
+	if mode == ModeDNA {
+		sites, err := palindrome.DNASites(p.Text, s.cfg.MinSiteLength)
+		if err != nil {
+			if err == palindrome.ErrInvalidNucleotide {
+				return store.MessagePayload{}, ErrInvalidSequence
+			}
+			return store.MessagePayload{}, err
+		}
+		for _, site := range sites {
+			payload.Sites = append(payload.Sites, store.Site{
+				Sequence: site.Sequence,
+				Start:    site.Start,
+				End:      site.End,
+				Length:   site.Length,
+			})
+		}
+	}
+	if mode == ModeNumeric {
+		if len(p.Text) > MaxNumberDigits {
+			return store.MessagePayload{}, ErrNumberTooLarge
+		}
+		n, err := palindrome.ParseNumber(p.Text)
+		if err != nil {
+			return store.MessagePayload{}, ErrInvalidNumber
+		}
+		payload.Bases = palindrome.PalindromicBases(n)
+	}
+	// The distance is computed in the units the checker compares, so it is 0 exactly if the Message is a palindrome.
+	// A Message that is too long or cannot be compared, like an empty sequence, has no distance.
+	if r, ok := checker.(palindrome.Repairer); ok {
+		repair, err := r.Repair(p.Text, s.cfg.DistanceSubstitutions)
+		if err == nil {
+			payload.Distance = &repair.Distance
+			payload.Suggestion = repair.Suggestion
+		}
+	}
+	return payload, nil
+}
+
 func toMessage(msg store.Message) Message {
 	return Message{
 		ID:         msg.ID,
 		Text:       msg.Text,
+		Length:     msg.Length,
 		Palindrome: msg.Palindrome,
-		CreatedAt:  msg.CreatedAt,
+		Mode:       Mode(msg.Mode),
+		Lang:       msg.Lang,
+		LongestPalindrome: Substring{
+			Text:   msg.LongestPalindrome.Text,
+			Start:  msg.LongestPalindrome.Start,
+			End:    msg.LongestPalindrome.End,
+			Length: msg.LongestPalindrome.Length,
+		},
+		Distance:      msg.Distance,
+		Suggestion:    msg.Suggestion,
+		Rearrangeable: msg.Rearrangeable,
+		Arrangement:   msg.Arrangement,
+		Sites:         toSites(msg.Sites),
+		Bases:         msg.Bases,
+		CreatedAt:     formatTime(msg.CreatedAt),
+		UpdatedAt:     formatTime(msg.UpdatedAt),
+		Version:       msg.Version,
+		DeletedAt:     formatTime(msg.DeletedAt),
 	}
 }
 
+// formatTime formats t with store.TimeLayout, or returns an empty string if t is zero.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(store.TimeLayout)
+}
+
+func toSites(sites []store.Site) []Site {
+	var res []Site
+	for _, site := range sites {
+		res = append(res, Site{
+			Sequence: site.Sequence,
+			Start:    site.Start,
+			End:      site.End,
+			Length:   site.Length,
+		})
+	}
+	return res
+}
+
 func toSlice(msgs []store.Message) []Message {
 	var res []Message
 	for _, msg := range msgs {
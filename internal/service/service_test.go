@@ -3,10 +3,13 @@ package service
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/nicholaslam/example-service/internal/idgen"
 	"github.com/nicholaslam/example-service/internal/store"
+	"github.com/nicholaslam/example-service/pkg/palindrome"
 	"github.com/stretchr/testify/require"
 )
 
@@ -24,11 +27,57 @@ func (ms *mockStore) Read(ctx context.Context, id string) (store.Message, error)
 	return ms.msg, ms.err
 }
 
-func (ms *mockStore) List(ctx context.Context, p store.ListPayload) ([]store.Message, error) {
+func (ms *mockStore) ReadAt(ctx context.Context, id string, at time.Time) (store.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockStore) Update(ctx context.Context, id string, p store.MessagePayload, version *int) (store.Message, error) {
+	if ms.err != nil {
+		return store.Message{}, ms.err
+	}
+	return store.Message{
+		ID:         id,
+		Text:       p.Text,
+		Palindrome: p.Palindrome,
+		Mode:       p.Mode,
+		Lang:       p.Lang,
+		CreatedAt:  ms.msg.CreatedAt,
+		UpdatedAt:  ms.msg.CreatedAt,
+	}, nil
+}
+
+func (ms *mockStore) List(ctx context.Context, p store.ListPayload) (store.Page, error) {
+	return store.Page{Messages: ms.msgs}, ms.err
+}
+
+func (ms *mockStore) Delete(ctx context.Context, id string, version *int) error {
+	return ms.err
+}
+
+func (ms *mockStore) Trash(ctx context.Context) ([]store.Message, error) {
 	return ms.msgs, ms.err
 }
 
-func (ms *mockStore) Delete(ctx context.Context, id string) error {
+func (ms *mockStore) Restore(ctx context.Context, id string) (store.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockStore) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	return len(ms.msgs), ms.err
+}
+
+func (ms *mockStore) Revisions(ctx context.Context, id string) ([]store.Revision, error) {
+	if ms.err != nil {
+		return []store.Revision{}, ms.err
+	}
+	revs := []store.Revision{}
+	for _, msg := range ms.msgs {
+		revs = append(revs, store.Revision{Message: msg, RevisedAt: msg.CreatedAt})
+	}
+	return revs, nil
+}
+
+func (ms *mockStore) Purge(ctx context.Context, id string) error {
 	return ms.err
 }
 
@@ -36,17 +85,148 @@ func toBoolPointer(b bool) *bool {
 	return &b
 }
 
+func toIntPointer(i int) *int {
+	return &i
+}
+
 func TestNewService(t *testing.T) {
-	require.NotNil(t, NewService(&mockStore{}, true))
+	require.NotNil(t, NewService(&mockStore{}, Config{Mode: ModeStrict}))
+}
+
+func TestCreateCheckers(t *testing.T) {
+	checkers := palindrome.NewDefaultRegistry(palindrome.UnicodeOptions{StripDiacritics: true})
+	checkers.Register("always", palindrome.CheckerFunc(func(s string) bool {
+		return true
+	}))
+	glyphs := palindrome.RotationGlyphs()
+	glyphs.AddPair('a', 'e')
+	checkers.Register("ambigram", glyphs)
+
+	testCases := []struct {
+		name   string
+		mode   Mode
+		text   string
+		want   bool
+		errMsg string
+	}{
+		{
+			"strict",
+			ModeStrict,
+			"a toyota",
+			false,
+			"",
+		},
+		{
+			"lenient",
+			ModeLenient,
+			"a toyota",
+			true,
+			"",
+		},
+		{
+			"lenient non-english",
+			ModeLenient,
+			"Ésope reste ici et se repose",
+			false,
+			"",
+		},
+		{
+			"unicode",
+			ModeUnicode,
+			"Ésope reste ici et se repose",
+			true,
+			"",
+		},
+		{
+			"grapheme",
+			ModeGrapheme,
+			"\U0001F468\u200d\U0001F469\u200d\U0001F467 a \U0001F467\u200d\U0001F469\u200d\U0001F468",
+			false,
+			"",
+		},
+		{
+			"word",
+			ModeWord,
+			"Fall leaves after leaves fall",
+			true,
+			"",
+		},
+		{
+			"line",
+			ModeLine,
+			"Dawn breaks\nthe river runs\ndawn breaks",
+			true,
+			"",
+		},
+		{
+			"mirror",
+			ModeMirror,
+			"(xox)",
+			true,
+			"",
+		},
+		{
+			"rotation",
+			ModeRotation,
+			"pod",
+			true,
+			"",
+		},
+		{
+			"custom glyph table",
+			"ambigram",
+			"ape",
+			false,
+			"",
+		},
+		{
+			"custom glyph table palindrome",
+			"ambigram",
+			"ase",
+			true,
+			"",
+		},
+		{
+			"custom checker",
+			"always",
+			"abc",
+			true,
+			"",
+		},
+		{
+			"ErrInvalidMode",
+			"invalid",
+			"abc",
+			false,
+			ErrInvalidMode.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{Checkers: checkers, Mode: tc.mode})
+			msg, err := svc.Create(context.Background(), MessagePayload{Text: tc.text})
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msg.Palindrome)
+				require.Equal(t, tc.mode, msg.Mode)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
 }
 
 func TestCreate(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
 
 	testCases := []struct {
 		name    string
 		store   store.Store
-		strict  bool
+		cfg     Config
 		payload MessagePayload
 		want    Message
 		errMsg  string
@@ -58,46 +238,676 @@ func TestCreate(t *testing.T) {
 					ID:         "123",
 					Text:       "a toyota",
 					Palindrome: false,
-					CreatedAt:  now,
+					CreatedAt:  createdAt,
 				},
 				nil,
 				nil,
 			},
-			true,
+			Config{Mode: ModeStrict},
+			MessagePayload{
+				Text: "a toyota",
+			},
+			Message{
+				ID:         "123",
+				Text:       "a toyota",
+				Palindrome: false,
+				CreatedAt:  now,
+			},
+			"",
+		},
+		{
+			"non-strict palindrome",
+			&mockStore{
+				store.Message{
+					ID:         "123",
+					Text:       "a toyota",
+					Palindrome: true,
+					CreatedAt:  createdAt,
+				},
+				nil,
+				nil,
+			},
+			Config{Mode: ModeLenient},
 			MessagePayload{
 				Text: "a toyota",
 			},
-			Message{
-				ID:         "123",
-				Text:       "a toyota",
-				Palindrome: false,
-				CreatedAt:  now,
+			Message{
+				ID:         "123",
+				Text:       "a toyota",
+				Palindrome: true,
+				CreatedAt:  now,
+			},
+			"",
+		},
+		{
+			"unhandled error",
+			&mockStore{
+				store.Message{},
+				nil,
+				errors.New("error"),
+			},
+			Config{Mode: ModeLenient},
+			MessagePayload{
+				Text: "",
+			},
+			Message{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(tc.store, tc.cfg)
+			msg, err := svc.Create(context.Background(), tc.payload)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msg)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestCreateLongestPalindrome(t *testing.T) {
+	svc := NewService(store.NewTempStore(idgen.NewULID()), Config{Mode: ModeStrict})
+	msg, err := svc.Create(context.Background(), MessagePayload{Text: "my racecar"})
+	require.NoError(t, err)
+	require.False(t, msg.Palindrome)
+	require.Equal(t, Substring{"racecar", 3, 10, 7}, msg.LongestPalindrome)
+}
+
+func TestCreateDistance(t *testing.T) {
+	testCases := []struct {
+		name          string
+		substitutions bool
+		text          string
+		distance      *int
+		suggestion    string
+	}{
+		{
+			"insertions",
+			false,
+			"racecr",
+			toIntPointer(1),
+			"racecar",
+		},
+		{
+			"substitutions",
+			true,
+			"abcdeba",
+			toIntPointer(1),
+			"abcdcba",
+		},
+		{
+			"too long",
+			false,
+			strings.Repeat("ab", palindrome.MaxDistanceLength),
+			nil,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{DistanceSubstitutions: tc.substitutions})
+			msg, err := svc.Create(context.Background(), MessagePayload{Text: tc.text})
+			require.NoError(t, err)
+			require.Equal(t, tc.distance, msg.Distance)
+			require.Equal(t, tc.suggestion, msg.Suggestion)
+		})
+	}
+}
+
+func TestCreateDistanceMatchesPalindrome(t *testing.T) {
+	testCases := []struct {
+		mode  Mode
+		texts []string
+	}{
+		{ModeStrict, []string{"racecar", "Racecar", "été", "ab"}},
+		{ModeLenient, []string{"A man, a plan, a canal: Panama", "a toyota", "abc!"}},
+		{ModeUnicode, []string{"А роза упала на лапу Азора", "Ésope reste ici et se repose", "abc"}},
+		{ModeGrapheme, []string{"🇫🇷 a 🇫🇷", "🇫🇷 a 🇩🇪", "noon"}},
+		{ModeWord, []string{"Fall leaves after leaves fall", "Fall leaves after fall", "one"}},
+		{ModeLine, []string{"roses\nare red\nRoses!", "roses\nare red", ""}},
+		{ModeDNA, []string{"GAATTC", "gaattg", "GAC", "S"}},
+		{ModeNumeric, []string{"585", "0110", "12"}},
+		{ModeMirror, []string{"(xox)", "bid", "bike"}},
+		{ModeRotation, []string{"pod", "SWIMS", "dog"}},
+	}
+
+	for _, tc := range testCases {
+		for _, substitutions := range []bool{false, true} {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{DistanceSubstitutions: substitutions})
+			for _, text := range tc.texts {
+				msg, err := svc.Create(context.Background(), MessagePayload{Text: text, Mode: tc.mode})
+				require.NoError(t, err)
+				require.NotNil(t, msg.Distance, "%s %q", tc.mode, text)
+				require.Equal(t, msg.Palindrome, *msg.Distance == 0, "%s %q", tc.mode, text)
+			}
+		}
+	}
+}
+
+func TestListMaxDistanceLenient(t *testing.T) {
+	svc := NewService(store.NewTempStore(idgen.NewULID()), Config{Mode: ModeLenient})
+	created, err := svc.Create(context.Background(), MessagePayload{Text: "A man, a plan, a canal: Panama"})
+	require.NoError(t, err)
+	require.True(t, created.Palindrome)
+	require.Equal(t, 0, *created.Distance)
+	require.Equal(t, "amanaplanacanalpanama", created.Suggestion)
+	_, err = svc.Create(context.Background(), MessagePayload{Text: "a plan"})
+	require.NoError(t, err)
+
+	page, err := svc.List(context.Background(), ListPayload{MaxDistance: toIntPointer(0)})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 1)
+	require.Equal(t, created.ID, page.Messages[0].ID)
+}
+
+func TestCreateDistanceWithoutRepairer(t *testing.T) {
+	checkers := palindrome.NewRegistry()
+	checkers.Register("custom", palindrome.CheckerFunc(palindrome.IsPalindrome))
+	svc := NewService(store.NewTempStore(idgen.NewULID()), Config{Checkers: checkers, Mode: "custom"})
+	msg, err := svc.Create(context.Background(), MessagePayload{Text: "racecar"})
+	require.NoError(t, err)
+	require.True(t, msg.Palindrome)
+	require.Nil(t, msg.Distance)
+	require.Empty(t, msg.Suggestion)
+}
+
+func TestCreateRearrangeable(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		rearrangeable bool
+		arrangement   string
+	}{
+		{
+			"rearrangeable",
+			"Tact Coa",
+			true,
+			"actotca",
+		},
+		{
+			"not rearrangeable",
+			"abc",
+			false,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{})
+			msg, err := svc.Create(context.Background(), MessagePayload{Text: tc.text})
+			require.NoError(t, err)
+			require.False(t, msg.Palindrome)
+			require.Equal(t, tc.rearrangeable, msg.Rearrangeable)
+			require.Equal(t, tc.arrangement, msg.Arrangement)
+		})
+	}
+}
+
+func TestCreateDNA(t *testing.T) {
+	testCases := []struct {
+		name          string
+		text          string
+		mode          Mode
+		minSiteLength int
+		palindrome    bool
+		sites         []Site
+		errMsg        string
+	}{
+		{
+			"palindrome",
+			"GAATTC",
+			ModeDNA,
+			0,
+			true,
+			[]Site{
+				{"GAATTC", 0, 6, 6},
+			},
+			"",
+		},
+		{
+			"sites",
+			"aaGAATTCgtGGATCCt",
+			ModeDNA,
+			6,
+			false,
+			[]Site{
+				{"GAATTC", 2, 8, 6},
+				{"GGATCC", 10, 16, 6},
+			},
+			"",
+		},
+		{
+			"no sites",
+			"GAATTC",
+			ModeDNA,
+			8,
+			true,
+			nil,
+			"",
+		},
+		{
+			"other mode",
+			"GAATTC",
+			ModeStrict,
+			0,
+			false,
+			nil,
+			"",
+		},
+		{
+			"ErrInvalidSequence",
+			"racecar",
+			ModeDNA,
+			0,
+			false,
+			nil,
+			ErrInvalidSequence.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{MinSiteLength: tc.minSiteLength})
+			msg, err := svc.Create(context.Background(), MessagePayload{Text: tc.text, Mode: tc.mode})
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.palindrome, msg.Palindrome)
+				require.Equal(t, tc.sites, msg.Sites)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestCreateNumeric(t *testing.T) {
+	testCases := []struct {
+		name       string
+		text       string
+		palindrome bool
+		bases      []int
+		errMsg     string
+	}{
+		{
+			"palindrome",
+			"585",
+			true,
+			[]int{2, 8, 10},
+			"",
+		},
+		{
+			"not a palindrome",
+			"1000",
+			false,
+			[]int{9, 27},
+			"",
+		},
+		{
+			"large number",
+			"12345678987654321012345678987654321",
+			true,
+			[]int{10},
+			"",
+		},
+		{
+			"ErrInvalidNumber",
+			"racecar",
+			false,
+			nil,
+			ErrInvalidNumber.Error(),
+		},
+		{
+			"longest number",
+			"1" + strings.Repeat("0", MaxNumberDigits-2) + "1",
+			true,
+			[]int{10},
+			"",
+		},
+		{
+			"ErrNumberTooLarge",
+			"1" + strings.Repeat("0", MaxNumberDigits),
+			false,
+			nil,
+			ErrNumberTooLarge.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{})
+			msg, err := svc.Create(context.Background(), MessagePayload{Text: tc.text, Mode: ModeNumeric})
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.palindrome, msg.Palindrome)
+				require.Equal(t, tc.bases, msg.Bases)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestCreateLang(t *testing.T) {
+	testCases := []struct {
+		name       string
+		text       string
+		mode       Mode
+		lang       string
+		palindrome bool
+		wantLang   string
+		errMsg     string
+	}{
+		{
+			"no language",
+			"ßaass",
+			ModeLenient,
+			"",
+			false,
+			"",
+			"",
+		},
+		{
+			"english",
+			"Iı",
+			ModeLenient,
+			"en",
+			false,
+			"en",
+			"",
+		},
+		{
+			"turkish",
+			"Iı",
+			ModeLenient,
+			"tr-tr",
+			true,
+			"tr-TR",
+			"",
+		},
+		{
+			"german",
+			"ßaass",
+			ModeLenient,
+			"de",
+			true,
+			"de",
+			"",
+		},
+		{
+			"invalid language",
+			"racecar",
+			ModeLenient,
+			"t",
+			false,
+			"",
+			ErrInvalidLanguage.Error(),
+		},
+		{
+			"other mode",
+			"racecar",
+			ModeStrict,
+			"tr",
+			false,
+			"",
+			ErrInvalidLanguage.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{})
+			msg, err := svc.Create(context.Background(), MessagePayload{Text: tc.text, Mode: tc.mode, Lang: tc.lang})
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.palindrome, msg.Palindrome)
+				require.Equal(t, tc.wantLang, msg.Lang)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestCreateMode(t *testing.T) {
+	testCases := []struct {
+		name    string
+		payload MessagePayload
+		want    Message
+		errMsg  string
+	}{
+		{
+			"default mode",
+			MessagePayload{
+				Text: "Fall leaves after leaves fall",
+			},
+			Message{
+				Text:       "Fall leaves after leaves fall",
+				Palindrome: false,
+				Mode:       ModeLenient,
+			},
+			"",
+		},
+		{
+			"requested mode",
+			MessagePayload{
+				Text: "Fall leaves after leaves fall",
+				Mode: ModeWord,
+			},
+			Message{
+				Text:       "Fall leaves after leaves fall",
+				Palindrome: true,
+				Mode:       ModeWord,
+			},
+			"",
+		},
+		{
+			"ErrInvalidMode",
+			MessagePayload{
+				Text: "Fall leaves after leaves fall",
+				Mode: "invalid",
+			},
+			Message{},
+			ErrInvalidMode.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(store.NewTempStore(idgen.NewULID()), Config{Mode: ModeLenient})
+			msg, err := svc.Create(context.Background(), tc.payload)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want.Text, msg.Text)
+				require.Equal(t, tc.want.Palindrome, msg.Palindrome)
+				require.Equal(t, tc.want.Mode, msg.Mode)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestRead(t *testing.T) {
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		id     string
+		want   Message
+		errMsg string
+	}{
+		{
+			"success",
+			&mockStore{
+				store.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  createdAt,
+				},
+				nil,
+				nil,
+			},
+			"123",
+			Message{
+				ID:         "123",
+				Text:       "racecar",
+				Palindrome: true,
+				CreatedAt:  now,
+			},
+			"",
+		},
+		{
+			"store.ErrNotFound",
+			&mockStore{
+				store.Message{},
+				nil,
+				store.ErrNotFound,
+			},
+			"",
+			Message{},
+			ErrNotFound.Error(),
+		},
+		{
+			"unhandled error",
+			&mockStore{
+				store.Message{},
+				nil,
+				errors.New("error"),
+			},
+			"",
+			Message{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			msg, err := svc.Read(context.Background(), tc.id)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msg)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		want   Message
+		errMsg string
+	}{
+		{
+			"success",
+			&mockStore{
+				store.Message{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: createdAt, Version: 1},
+				nil,
+				nil,
+			},
+			Message{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: now, Version: 1},
+			"",
+		},
+		{
+			"store.ErrNotFound",
+			&mockStore{
+				store.Message{},
+				nil,
+				store.ErrNotFound,
+			},
+			Message{},
+			ErrNotFound.Error(),
+		},
+		{
+			"unhandled error",
+			&mockStore{
+				store.Message{},
+				nil,
+				errors.New("error"),
+			},
+			Message{},
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			msg, err := svc.ReadAt(context.Background(), "123", time.Now())
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msg)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		want   []Revision
+		errMsg string
+	}{
+		{
+			"success",
+			&mockStore{
+				store.Message{},
+				[]store.Message{
+					{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: createdAt, Version: 1},
+					{ID: "123", Text: "abc", CreatedAt: createdAt, UpdatedAt: createdAt, Version: 2},
+				},
+				nil,
+			},
+			[]Revision{
+				{Message{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: now, Version: 1}, now, false},
+				{Message{ID: "123", Text: "abc", CreatedAt: now, UpdatedAt: now, Version: 2}, now, false},
 			},
 			"",
 		},
 		{
-			"non-strict palindrome",
+			"store.ErrNotFound",
 			&mockStore{
-				store.Message{
-					ID:         "123",
-					Text:       "a toyota",
-					Palindrome: true,
-					CreatedAt:  now,
-				},
-				nil,
+				store.Message{},
 				nil,
+				store.ErrNotFound,
 			},
-			false,
-			MessagePayload{
-				Text: "a toyota",
-			},
-			Message{
-				ID:         "123",
-				Text:       "a toyota",
-				Palindrome: true,
-				CreatedAt:  now,
-			},
-			"",
+			[]Revision{},
+			ErrNotFound.Error(),
 		},
 		{
 			"unhandled error",
@@ -106,62 +916,105 @@ func TestCreate(t *testing.T) {
 				nil,
 				errors.New("error"),
 			},
-			false,
-			MessagePayload{
-				Text: "",
-			},
-			Message{},
+			[]Revision{},
 			"error",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewService(tc.store, tc.strict)
-			msg, err := svc.Create(context.Background(), tc.payload)
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			revs, err := svc.Revisions(context.Background(), "123")
 			if tc.errMsg == "" {
 				require.NoError(t, err)
-				require.Equal(t, tc.want, msg)
 			} else {
 				require.Error(t, err)
 				require.Equal(t, tc.errMsg, err.Error())
-				require.Empty(t, msg)
 			}
+			require.Equal(t, tc.want, revs)
 		})
 	}
 }
 
-func TestRead(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+func TestUpdate(t *testing.T) {
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
+	text := func(s string) *string { return &s }
+	mode := func(m Mode) *Mode { return &m }
 
 	testCases := []struct {
-		name   string
-		store  store.Store
-		id     string
-		want   Message
-		errMsg string
+		name    string
+		store   store.Store
+		payload UpdatePayload
+		want    Message
+		errMsg  string
 	}{
 		{
-			"success",
+			"text",
 			&mockStore{
-				store.Message{
-					ID:         "123",
-					Text:       "racecar",
-					Palindrome: true,
-					CreatedAt:  now,
-				},
+				store.Message{ID: "123", Text: "racecar", Palindrome: true, Mode: "strict", CreatedAt: createdAt},
 				nil,
 				nil,
 			},
-			"123",
-			Message{
-				ID:         "123",
-				Text:       "racecar",
-				Palindrome: true,
-				CreatedAt:  now,
+			UpdatePayload{Text: text("abc")},
+			Message{ID: "123", Text: "abc", Palindrome: false, Mode: ModeStrict, CreatedAt: now, UpdatedAt: now},
+			"",
+		},
+		{
+			"mode",
+			&mockStore{
+				store.Message{ID: "123", Text: "Racecar", Palindrome: false, Mode: "strict", CreatedAt: createdAt},
+				nil,
+				nil,
+			},
+			UpdatePayload{Mode: mode(ModeLenient)},
+			Message{ID: "123", Text: "Racecar", Palindrome: true, Mode: ModeLenient, CreatedAt: now, UpdatedAt: now},
+			"",
+		},
+		{
+			"lang",
+			&mockStore{
+				store.Message{ID: "123", Text: "ßaass", Palindrome: false, Mode: "lenient", CreatedAt: createdAt},
+				nil,
+				nil,
 			},
+			UpdatePayload{Lang: text("de")},
+			Message{ID: "123", Text: "ßaass", Palindrome: true, Mode: ModeLenient, Lang: "de", CreatedAt: now, UpdatedAt: now},
 			"",
 		},
+		{
+			"mode drops lang",
+			&mockStore{
+				store.Message{ID: "123", Text: "ßaass", Palindrome: true, Mode: "lenient", Lang: "de", CreatedAt: createdAt},
+				nil,
+				nil,
+			},
+			UpdatePayload{Mode: mode(ModeStrict)},
+			Message{ID: "123", Text: "ßaass", Palindrome: false, Mode: ModeStrict, CreatedAt: now, UpdatedAt: now},
+			"",
+		},
+		{
+			"ErrInvalidLanguage",
+			&mockStore{
+				store.Message{ID: "123", Text: "racecar", Palindrome: true, Mode: "strict", CreatedAt: createdAt},
+				nil,
+				nil,
+			},
+			UpdatePayload{Lang: text("tr")},
+			Message{},
+			ErrInvalidLanguage.Error(),
+		},
+		{
+			"ErrInvalidMode",
+			&mockStore{
+				store.Message{ID: "123", Text: "racecar", Palindrome: true, Mode: "strict", CreatedAt: createdAt},
+				nil,
+				nil,
+			},
+			UpdatePayload{Mode: mode("invalid")},
+			Message{},
+			ErrInvalidMode.Error(),
+		},
 		{
 			"store.ErrNotFound",
 			&mockStore{
@@ -169,7 +1022,7 @@ func TestRead(t *testing.T) {
 				nil,
 				store.ErrNotFound,
 			},
-			"",
+			UpdatePayload{Text: text("abc")},
 			Message{},
 			ErrNotFound.Error(),
 		},
@@ -180,7 +1033,7 @@ func TestRead(t *testing.T) {
 				nil,
 				errors.New("error"),
 			},
-			"",
+			UpdatePayload{Text: text("abc")},
 			Message{},
 			"error",
 		},
@@ -188,8 +1041,8 @@ func TestRead(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewService(tc.store, true)
-			msg, err := svc.Read(context.Background(), tc.id)
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			msg, err := svc.Update(context.Background(), "123", tc.payload)
 			if tc.errMsg == "" {
 				require.NoError(t, err)
 				require.Equal(t, tc.want, msg)
@@ -202,8 +1055,56 @@ func TestRead(t *testing.T) {
 	}
 }
 
+// racingStore changes a Message between the first Read and Update of the Service, like a concurrent request.
+type racingStore struct {
+	store.Store
+	raced bool
+}
+
+func (rs *racingStore) Update(ctx context.Context, id string, p store.MessagePayload, version *int) (store.Message, error) {
+	if !rs.raced {
+		rs.raced = true
+		_, err := rs.Store.Update(ctx, id, store.MessagePayload{Text: "Racecar", Mode: string(ModeLenient), Palindrome: true}, nil)
+		if err != nil {
+			return store.Message{}, err
+		}
+	}
+	return rs.Store.Update(ctx, id, p, version)
+}
+
+func TestUpdateConcurrentChange(t *testing.T) {
+	rs := &racingStore{Store: store.NewTempStore(idgen.NewULID())}
+	svc := NewService(rs, Config{Mode: ModeStrict})
+	created, err := svc.Create(context.Background(), MessagePayload{Text: "racecar"})
+	require.NoError(t, err)
+
+	text := "Abba"
+	msg, err := svc.Update(context.Background(), created.ID, UpdatePayload{Text: &text})
+	require.NoError(t, err)
+	require.Equal(t, "Abba", msg.Text)
+	require.Equal(t, ModeLenient, msg.Mode)
+	require.True(t, msg.Palindrome)
+	require.Equal(t, 3, msg.Version)
+}
+
+func TestDeletePurgeTrashed(t *testing.T) {
+	ts := store.NewTempStore(idgen.NewULID())
+	svc := NewService(ts, Config{})
+	created, err := svc.Create(context.Background(), MessagePayload{Text: "racecar"})
+	require.NoError(t, err)
+	require.NoError(t, svc.Delete(context.Background(), created.ID, DeletePayload{}))
+	require.Equal(t, ErrNotFound, svc.Delete(context.Background(), created.ID, DeletePayload{}))
+
+	require.NoError(t, svc.Delete(context.Background(), created.ID, DeletePayload{Purge: true}))
+	trash, err := ts.Trash(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, trash)
+	require.Equal(t, ErrNotFound, svc.Delete(context.Background(), created.ID, DeletePayload{Purge: true}))
+}
+
 func TestList(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
 
 	testCases := []struct {
 		name        string
@@ -222,19 +1123,56 @@ func TestList(t *testing.T) {
 						ID:         "123",
 						Text:       "racecar",
 						Palindrome: true,
-						CreatedAt:  now,
+						CreatedAt:  createdAt,
 					},
 					{
 						ID:         "456",
 						Text:       "a toyota",
 						Palindrome: false,
-						CreatedAt:  now,
+						CreatedAt:  createdAt,
 					},
 					{
 						ID:         "789",
 						Text:       "abc",
 						Palindrome: false,
-						CreatedAt:  now,
+						CreatedAt:  createdAt,
+					},
+				},
+				nil,
+			},
+			[]Message{
+				{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+				},
+				{
+					ID:         "456",
+					Text:       "a toyota",
+					Palindrome: false,
+					CreatedAt:  now,
+				},
+				{
+					ID:         "789",
+					Text:       "abc",
+					Palindrome: false,
+					CreatedAt:  now,
+				},
+			},
+			"",
+		},
+		{
+			"palindrome=true",
+			ListPayload{Palindrome: toBoolPointer(true)},
+			&mockStore{
+				store.Message{},
+				[]store.Message{
+					{
+						ID:         "123",
+						Text:       "racecar",
+						Palindrome: true,
+						CreatedAt:  createdAt,
 					},
 				},
 				nil,
@@ -246,6 +1184,31 @@ func TestList(t *testing.T) {
 					Palindrome: true,
 					CreatedAt:  now,
 				},
+			},
+			"",
+		},
+		{
+			"palindrome=false",
+			ListPayload{Palindrome: toBoolPointer(false)},
+			&mockStore{
+				store.Message{},
+				[]store.Message{
+					{
+						ID:         "456",
+						Text:       "a toyota",
+						Palindrome: false,
+						CreatedAt:  createdAt,
+					},
+					{
+						ID:         "789",
+						Text:       "abc",
+						Palindrome: false,
+						CreatedAt:  createdAt,
+					},
+				},
+				nil,
+			},
+			[]Message{
 				{
 					ID:         "456",
 					Text:       "a toyota",
@@ -259,137 +1222,255 @@ func TestList(t *testing.T) {
 					CreatedAt:  now,
 				},
 			},
-			"",
+			"",
+		},
+		{
+			"ErrInvalidBase",
+			ListPayload{Base: toIntPointer(37)},
+			&mockStore{},
+			nil,
+			ErrInvalidBase.Error(),
+		},
+		{
+			"ErrInvalidSort",
+			ListPayload{Sort: "distance"},
+			&mockStore{},
+			nil,
+			ErrInvalidSort.Error(),
+		},
+		{
+			"store.ErrInvalidCursor",
+			ListPayload{Cursor: "invalid"},
+			&mockStore{
+				store.Message{},
+				nil,
+				store.ErrInvalidCursor,
+			},
+			nil,
+			ErrInvalidCursor.Error(),
+		},
+		{
+			"unhandled error",
+			ListPayload{Palindrome: nil},
+			&mockStore{
+				store.Message{},
+				nil,
+				errors.New("error"),
+			},
+			nil,
+			"error",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			page, err := svc.List(context.Background(), tc.listPayload)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, page.Messages)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, page.Messages)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	createdAt := time.Now().UTC()
+
+	testCases := []struct {
+		name    string
+		store   store.Store
+		id      string
+		payload DeletePayload
+		errMsg  string
+	}{
+		{
+			"success",
+			&mockStore{
+				store.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  createdAt,
+				},
+				nil,
+				nil,
+			},
+			"123",
+			DeletePayload{},
+			"",
+		},
+		{
+			"store.ErrNotFound",
+			&mockStore{
+				store.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  createdAt,
+				},
+				nil,
+				store.ErrNotFound,
+			},
+			"456",
+			DeletePayload{},
+			ErrNotFound.Error(),
+		},
+		{
+			"purge",
+			&mockStore{
+				store.Message{},
+				nil,
+				nil,
+			},
+			"123",
+			DeletePayload{Purge: true},
+			"",
+		},
+		{
+			"purge store.ErrNotFound",
+			&mockStore{
+				store.Message{},
+				nil,
+				store.ErrNotFound,
+			},
+			"456",
+			DeletePayload{Purge: true},
+			ErrNotFound.Error(),
 		},
 		{
-			"palindrome=true",
-			ListPayload{Palindrome: toBoolPointer(true)},
+			"store.ErrNotFound with version",
 			&mockStore{
 				store.Message{},
-				[]store.Message{
-					{
-						ID:         "123",
-						Text:       "racecar",
-						Palindrome: true,
-						CreatedAt:  now,
-					},
-				},
 				nil,
+				store.ErrNotFound,
 			},
-			[]Message{
-				{
-					ID:         "123",
-					Text:       "racecar",
-					Palindrome: true,
-					CreatedAt:  now,
-				},
+			"456",
+			DeletePayload{Version: toIntPointer(1)},
+			ErrVersionMismatch.Error(),
+		},
+		{
+			"store.ErrVersionMismatch",
+			&mockStore{
+				store.Message{},
+				nil,
+				store.ErrVersionMismatch,
+			},
+			"123",
+			DeletePayload{Version: toIntPointer(1)},
+			ErrVersionMismatch.Error(),
+		},
+		{
+			"unhandled error",
+			&mockStore{
+				store.Message{},
+				nil,
+				errors.New("error"),
 			},
 			"",
+			DeletePayload{},
+			"error",
 		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			err := svc.Delete(context.Background(), tc.id, tc.payload)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestTrash(t *testing.T) {
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
+
+	testCases := []struct {
+		name   string
+		store  store.Store
+		want   []Message
+		errMsg string
+	}{
 		{
-			"palindrome=false",
-			ListPayload{Palindrome: toBoolPointer(false)},
+			"success",
 			&mockStore{
 				store.Message{},
 				[]store.Message{
-					{
-						ID:         "456",
-						Text:       "a toyota",
-						Palindrome: false,
-						CreatedAt:  now,
-					},
-					{
-						ID:         "789",
-						Text:       "abc",
-						Palindrome: false,
-						CreatedAt:  now,
-					},
+					{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: createdAt, Version: 1, DeletedAt: createdAt},
 				},
 				nil,
 			},
 			[]Message{
-				{
-					ID:         "456",
-					Text:       "a toyota",
-					Palindrome: false,
-					CreatedAt:  now,
-				},
-				{
-					ID:         "789",
-					Text:       "abc",
-					Palindrome: false,
-					CreatedAt:  now,
-				},
+				{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: now, Version: 1, DeletedAt: now},
 			},
 			"",
 		},
 		{
 			"unhandled error",
-			ListPayload{Palindrome: nil},
 			&mockStore{
 				store.Message{},
 				nil,
 				errors.New("error"),
 			},
-			nil,
+			[]Message{},
 			"error",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewService(tc.store, true)
-			msgs, err := svc.List(context.Background(), tc.listPayload)
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			msgs, err := svc.Trash(context.Background())
 			if tc.errMsg == "" {
 				require.NoError(t, err)
-				require.Equal(t, tc.want, msgs)
 			} else {
 				require.Error(t, err)
 				require.Equal(t, tc.errMsg, err.Error())
-				require.Empty(t, msgs)
 			}
+			require.Equal(t, tc.want, msgs)
 		})
 	}
 }
 
-func TestDelete(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+func TestRestore(t *testing.T) {
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
 
 	testCases := []struct {
 		name   string
 		store  store.Store
-		id     string
+		want   Message
 		errMsg string
 	}{
 		{
 			"success",
 			&mockStore{
-				store.Message{
-					ID:         "123",
-					Text:       "racecar",
-					Palindrome: true,
-					CreatedAt:  now,
-				},
+				store.Message{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: createdAt, Version: 2},
 				nil,
 				nil,
 			},
-			"123",
+			Message{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: now, Version: 2},
 			"",
 		},
 		{
 			"store.ErrNotFound",
 			&mockStore{
-				store.Message{
-					ID:         "123",
-					Text:       "racecar",
-					Palindrome: true,
-					CreatedAt:  now,
-				},
+				store.Message{},
 				nil,
 				store.ErrNotFound,
 			},
-			"456",
-			"",
+			Message{},
+			ErrNotFound.Error(),
 		},
 		{
 			"unhandled error",
@@ -398,26 +1479,129 @@ func TestDelete(t *testing.T) {
 				nil,
 				errors.New("error"),
 			},
-			"",
+			Message{},
 			"error",
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			svc := NewService(tc.store, true)
-			err := svc.Delete(context.Background(), tc.id)
+			svc := NewService(tc.store, Config{Mode: ModeStrict})
+			msg, err := svc.Restore(context.Background(), "123")
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msg)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestEmptyTrash(t *testing.T) {
+	testCases := []struct {
+		name      string
+		retention time.Duration
+		want      int
+	}{
+		{
+			"retention",
+			time.Hour,
+			1,
+		},
+		{
+			"no retention",
+			0,
+			0,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &mockStore{store.Message{}, []store.Message{{ID: "123"}}, nil}
+			svc := NewService(s, Config{Mode: ModeStrict, TrashRetention: tc.retention})
+			n, err := svc.EmptyTrash(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tc.want, n)
+		})
+	}
+}
+
+func TestCheckStream(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	testCases := []struct {
+		name   string
+		ctx    context.Context
+		text   string
+		mode   Mode
+		want   StreamResult
+		errMsg string
+	}{
+		{
+			"default mode",
+			context.Background(),
+			"a toyota",
+			"",
+			StreamResult{false, ModeStrict, 8},
+			"",
+		},
+		{
+			"lenient",
+			context.Background(),
+			"a toyota",
+			ModeLenient,
+			StreamResult{true, ModeLenient, 8},
+			"",
+		},
+		{
+			"strict",
+			context.Background(),
+			"racecar",
+			ModeStrict,
+			StreamResult{true, ModeStrict, 7},
+			"",
+		},
+		{
+			"ErrInvalidMode",
+			context.Background(),
+			"racecar",
+			ModeWord,
+			StreamResult{},
+			ErrInvalidMode.Error(),
+		},
+		{
+			"canceled context",
+			canceled,
+			"racecar",
+			ModeStrict,
+			StreamResult{},
+			context.Canceled.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := NewService(&mockStore{}, Config{})
+			res, err := svc.CheckStream(tc.ctx, strings.NewReader(tc.text), tc.mode)
 			if tc.errMsg == "" {
 				require.NoError(t, err)
+				require.Equal(t, tc.want, res)
 			} else {
 				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, res)
 			}
 		})
 	}
 }
 
 func TestToMessage(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
 
 	testCases := []struct {
 		name string
@@ -430,13 +1614,27 @@ func TestToMessage(t *testing.T) {
 				ID:         "123",
 				Text:       "racecar",
 				Palindrome: true,
-				CreatedAt:  now,
+				LongestPalindrome: store.Substring{
+					Text:   "racecar",
+					Start:  0,
+					End:    7,
+					Length: 7,
+				},
+				CreatedAt: createdAt,
+				Version:   1,
 			},
 			Message{
 				ID:         "123",
 				Text:       "racecar",
 				Palindrome: true,
-				CreatedAt:  now,
+				LongestPalindrome: Substring{
+					Text:   "racecar",
+					Start:  0,
+					End:    7,
+					Length: 7,
+				},
+				CreatedAt: now,
+				Version:   1,
 			},
 		},
 	}
@@ -450,7 +1648,8 @@ func TestToMessage(t *testing.T) {
 }
 
 func TestToSlice(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+	createdAt := time.Now().UTC()
+	now := createdAt.Format(store.TimeLayout)
 
 	testCases := []struct {
 		name string
@@ -464,13 +1663,13 @@ func TestToSlice(t *testing.T) {
 					ID:         "123",
 					Text:       "racecar",
 					Palindrome: true,
-					CreatedAt:  now,
+					CreatedAt:  createdAt,
 				},
 				{
 					ID:         "456",
 					Text:       "abc",
 					Palindrome: false,
-					CreatedAt:  now,
+					CreatedAt:  createdAt,
 				},
 			},
 			[]Message{
@@ -497,3 +1696,214 @@ func TestToSlice(t *testing.T) {
 		})
 	}
 }
+
+func TestPalindromes(t *testing.T) {
+	ts := store.NewTempStore(idgen.NewULID())
+	svc := NewService(ts, Config{})
+	msg, err := svc.Create(context.Background(), MessagePayload{Text: "abacaba"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name   string
+		id     string
+		p      PalindromesPayload
+		want   PalindromesPage
+		errMsg string
+	}{
+		{
+			"all",
+			msg.ID,
+			PalindromesPayload{},
+			PalindromesPage{
+				Palindromes: []PalindromicSubstring{
+					{"abacaba", 7, 1, 0},
+					{"bacab", 5, 1, 1},
+					{"aba", 3, 2, 0},
+					{"aca", 3, 1, 2},
+					{"a", 1, 4, 0},
+					{"b", 1, 2, 1},
+					{"c", 1, 1, 3},
+				},
+				Total: 7,
+			},
+			"",
+		},
+		{
+			"minimum length",
+			msg.ID,
+			PalindromesPayload{MinLength: 3},
+			PalindromesPage{
+				Palindromes: []PalindromicSubstring{
+					{"abacaba", 7, 1, 0},
+					{"bacab", 5, 1, 1},
+					{"aba", 3, 2, 0},
+					{"aca", 3, 1, 2},
+				},
+				Total: 4,
+			},
+			"",
+		},
+		{
+			"offset and limit",
+			msg.ID,
+			PalindromesPayload{Offset: 2, Limit: 2},
+			PalindromesPage{
+				Palindromes: []PalindromicSubstring{
+					{"aba", 3, 2, 0},
+					{"aca", 3, 1, 2},
+				},
+				Total: 7,
+			},
+			"",
+		},
+		{
+			"offset past the end",
+			msg.ID,
+			PalindromesPayload{Offset: 10},
+			PalindromesPage{
+				Palindromes: []PalindromicSubstring{},
+				Total:       7,
+			},
+			"",
+		},
+		{
+			"ErrNotFound",
+			"invalid",
+			PalindromesPayload{},
+			PalindromesPage{},
+			ErrNotFound.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, err := svc.Palindromes(context.Background(), tc.id, tc.p)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, page)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, page)
+			}
+		})
+	}
+}
+
+func TestPalindromesLimit(t *testing.T) {
+	svc := NewService(store.NewTempStore(idgen.NewULID()), Config{})
+	msg, err := svc.Create(context.Background(), MessagePayload{Text: strings.Repeat("ab", 1500)})
+	require.NoError(t, err)
+
+	page, err := svc.Palindromes(context.Background(), msg.ID, PalindromesPayload{})
+	require.NoError(t, err)
+	require.Len(t, page.Palindromes, DefaultPalindromesLimit)
+
+	page, err = svc.Palindromes(context.Background(), msg.ID, PalindromesPayload{Limit: 5000})
+	require.NoError(t, err)
+	require.Len(t, page.Palindromes, MaxPalindromesLimit)
+	require.Equal(t, 3000, page.Total)
+}
+
+func TestCheck(t *testing.T) {
+	testCases := []struct {
+		name   string
+		ps     []MessagePayload
+		want   []bool
+		errMsg string
+	}{
+		{
+			"empty",
+			nil,
+			[]bool{},
+			"",
+		},
+		{
+			"single",
+			[]MessagePayload{
+				{Text: "racecar"},
+			},
+			[]bool{true},
+			"",
+		},
+		{
+			"many",
+			[]MessagePayload{
+				{Text: "racecar"},
+				{Text: "a toyota"},
+				{Text: "a toyota", Mode: ModeLenient},
+			},
+			[]bool{true, false, true},
+			"",
+		},
+		{
+			"ErrInvalidMode",
+			[]MessagePayload{
+				{Text: "racecar"},
+				{Text: "racecar", Mode: "invalid"},
+			},
+			nil,
+			ErrInvalidMode.Error(),
+		},
+		{
+			"ErrTooManyPayloads",
+			make([]MessagePayload, MaxCheckPayloads+1),
+			nil,
+			ErrTooManyPayloads.Error(),
+		},
+		{
+			"ErrTooManyRunes",
+			[]MessagePayload{
+				{Text: strings.Repeat("a", MaxCheckRunes/2)},
+				{Text: strings.Repeat("é", MaxCheckRunes/2+1)},
+			},
+			nil,
+			ErrTooManyRunes.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := store.NewTempStore(idgen.NewULID())
+			svc := NewService(ts, Config{})
+			msgs, err := svc.Check(context.Background(), tc.ps)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				got := []bool{}
+				for i, msg := range msgs {
+					require.Empty(t, msg.ID)
+					require.Empty(t, msg.CreatedAt)
+					require.Equal(t, tc.ps[i].Text, msg.Text)
+					got = append(got, msg.Palindrome)
+				}
+				require.Equal(t, tc.want, got)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msgs)
+			}
+			stored, err := ts.List(context.Background(), store.ListPayload{})
+			require.NoError(t, err)
+			require.Empty(t, stored.Messages)
+		})
+	}
+}
+
+func TestCheckAnalysis(t *testing.T) {
+	svc := NewService(&mockStore{err: errors.New("store must not be used")}, Config{})
+	msgs, err := svc.Check(context.Background(), []MessagePayload{{Text: "racecars"}})
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	require.Equal(t, "racecar", msgs[0].LongestPalindrome.Text)
+	require.Equal(t, 1, *msgs[0].Distance)
+	require.Equal(t, ModeStrict, msgs[0].Mode)
+}
+
+func TestCheckContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	svc := NewService(store.NewTempStore(idgen.NewULID()), Config{})
+	msgs, err := svc.Check(ctx, []MessagePayload{{Text: "racecar"}})
+	require.Equal(t, context.Canceled, err)
+	require.Empty(t, msgs)
+}
@@ -0,0 +1,151 @@
+package store
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrInvalidCursor is returned if a cursor is malformed or was returned for a different sort order.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Sort is the field Messages are listed by. Messages with equal fields are listed in insertion order in memory and in files, and by ID in MongoDB.
+type Sort string
+
+// Fields Messages can be listed by.
+const (
+	SortCreatedAt Sort = "createdAt"
+	SortLength    Sort = "length"
+	SortText      Sort = "text"
+)
+
+// cursor is the position after the last Message of a Page, which is encoded to be opaque to clients.
+type cursor struct {
+	Sort       Sort   `json:"s"`
+	Descending bool   `json:"d,omitempty"`
+	ID         string `json:"i"`
+	// Text is the field of the Message if the sort field is a string, and Length if it is SortLength.
+	Text   string `json:"t,omitempty"`
+	Length int    `json:"l,omitempty"`
+	// Seq is the insertion order of the Message in memory, which breaks ties before ID.
+	Seq uint64 `json:"q,omitempty"`
+}
+
+func newCursor(msg Message, s Sort, descending bool) cursor {
+	c := cursor{
+		Sort:       s,
+		Descending: descending,
+		ID:         msg.ID,
+	}
+	switch s {
+	case SortCreatedAt:
+		c.Text = msg.CreatedAt.UTC().Format(TimeLayout)
+	case SortLength:
+		c.Length = msg.Length
+	case SortText:
+		c.Text = msg.Text
+	}
+	return c
+}
+
+func (c cursor) String() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// parseCursor decodes the cursor raw, which must have been returned for the same sort order as p.
+func parseCursor(raw string, p ListPayload) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	var c cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	if c.Sort != sortOf(p) || c.Descending != p.Descending || c.ID == "" {
+		return cursor{}, ErrInvalidCursor
+	}
+	if c.Sort == SortCreatedAt {
+		if _, err := time.Parse(TimeLayout, c.Text); err != nil {
+			return cursor{}, ErrInvalidCursor
+		}
+	}
+	return c, nil
+}
+
+// sortOf returns the sort field of p, which defaults to SortCreatedAt.
+func sortOf(p ListPayload) Sort {
+	if p.Sort == "" {
+		return SortCreatedAt
+	}
+	return p.Sort
+}
+
+// compare returns a negative number if a Message at a precedes a Message at b in ascending order, and a positive number if it follows it.
+func compare(a, b cursor) int {
+	if a.Text != b.Text {
+		if a.Text < b.Text {
+			return -1
+		}
+		return 1
+	}
+	if a.Length != b.Length {
+		return a.Length - b.Length
+	}
+	if a.Seq != b.Seq {
+		if a.Seq < b.Seq {
+			return -1
+		}
+		return 1
+	}
+	switch {
+	case a.ID < b.ID:
+		return -1
+	case a.ID > b.ID:
+		return 1
+	}
+	return 0
+}
+
+// paginate sorts the Messages of entries like p, and returns the page that follows the cursor of p.
+func paginate(entries []entry, p ListPayload) (Page, error) {
+	s := sortOf(p)
+	var after *cursor
+	if p.Cursor != "" {
+		c, err := parseCursor(p.Cursor, p)
+		if err != nil {
+			return Page{}, err
+		}
+		after = &c
+	}
+	// less orders Messages as listed, so it is reversed if the order is descending.
+	less := func(a, b cursor) bool {
+		if p.Descending {
+			return compare(a, b) > 0
+		}
+		return compare(a, b) < 0
+	}
+	at := func(e entry) cursor {
+		c := newCursor(e.msg, s, p.Descending)
+		c.Seq = e.seq
+		return c
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return less(at(entries[i]), at(entries[j]))
+	})
+	page := Page{Messages: []Message{}}
+	for i, e := range entries {
+		if after != nil && !less(*after, at(e)) {
+			continue
+		}
+		if p.Limit > 0 && len(page.Messages) == p.Limit {
+			page.Next = at(entries[i-1]).String()
+			break
+		}
+		page.Messages = append(page.Messages, e.msg)
+	}
+	return page, nil
+}
@@ -0,0 +1,716 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nicholaslam/example-service/internal/idgen"
+)
+
+var (
+	// ErrInvalidSyncPolicy is returned if no SyncPolicy has a name.
+	ErrInvalidSyncPolicy = errors.New("invalid sync policy")
+
+	// ErrCorrupt is returned if a snapshot of a file store does not match its checksums.
+	ErrCorrupt = errors.New("corrupt snapshot")
+
+	// ErrClosed is returned if a file store is changed or closed after it was closed.
+	ErrClosed = errors.New("store is closed")
+
+	// errInvalidRecord is returned if a record is truncated or does not match its checksum.
+	errInvalidRecord = errors.New("invalid record")
+
+	// errRecordTooLarge is returned if a record is larger than maxRecordSize.
+	errRecordTooLarge = errors.New("record too large")
+)
+
+// SyncPolicy is when the write-ahead log of a file store is flushed to disk.
+type SyncPolicy string
+
+// Policies of a file store.
+const (
+	// SyncAlways flushes each change before it returns, so no change is lost if the machine crashes.
+	SyncAlways SyncPolicy = "always"
+	// SyncInterval flushes the changes every syncInterval, so the last changes may be lost if the machine crashes.
+	SyncInterval SyncPolicy = "interval"
+	// SyncNever leaves flushing to the operating system, so changes survive if the process crashes, but may be lost if the machine crashes.
+	SyncNever SyncPolicy = "never"
+)
+
+const (
+	// syncInterval is how often the write-ahead log is flushed with SyncInterval.
+	syncInterval = time.Second
+
+	// Files of a file store are suffixed with their generation, which is incremented by each compaction.
+	snapshotPrefix = "snapshot."
+	walPrefix      = "wal."
+	// snapshotTemp is the file a snapshot is written to before it is renamed.
+	snapshotTemp = "snapshot.tmp"
+
+	// recordHeaderSize is the size of the length and checksum that precede the payload of a record.
+	recordHeaderSize = 8
+	maxRecordSize    = 64 << 20
+)
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ParseSyncPolicy returns the SyncPolicy with name.
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	switch p := SyncPolicy(name); p {
+	case SyncAlways, SyncInterval, SyncNever:
+		return p, nil
+	}
+	return "", ErrInvalidSyncPolicy
+}
+
+// FileStore describes a Store that persists Messages in files, which must be closed once it is no longer used.
+type FileStore interface {
+	Store
+	// Compact replaces the snapshot and write-ahead log by a snapshot of the current Messages, so the log does not grow forever.
+	Compact() error
+	Close() error
+}
+
+// op is the change a record makes to a tempStore.
+type op string
+
+const (
+	// opPut creates, updates, or restores Message.
+	opPut op = "put"
+	// opTrash moves Message to the trash.
+	opTrash op = "trash"
+	// opExpire deletes the Message with ID from the trash, and its history.
+	opExpire op = "expire"
+	// opPurge deletes the Message with ID and its history.
+	opPurge op = "purge"
+	// opLoad loads the Message with ID and its history from a snapshot.
+	opLoad op = "load"
+)
+
+// record is a change of a file store. Records are appended to the write-ahead log before they are applied, and a snapshot has a record of opLoad per Message.
+type record struct {
+	Op      op       `json:"op"`
+	ID      string   `json:"id"`
+	Message *Message `json:"message,omitempty"`
+	// Revision is appended to the history of the Message.
+	Revision *Revision `json:"revision,omitempty"`
+	// Trashed and Revisions are the state of the Message with opLoad. Message is nil if only its history is left.
+	Trashed   bool       `json:"trashed,omitempty"`
+	Revisions []Revision `json:"revisions,omitempty"`
+}
+
+type fileStore struct {
+	mem    *tempStore
+	ids    idgen.Generator
+	dir    string
+	policy SyncPolicy
+	// mu serializes changes, so records are applied in the order they are appended, and guards the fields below.
+	mu  sync.Mutex
+	wal *os.File
+	gen uint64
+	// size is the length of the records in wal.
+	size int64
+	// dirty is true if records were appended since wal was last flushed.
+	dirty bool
+	// failed is the error of a flush or truncation of wal, after which it is unknown which records are on disk, so no more changes are accepted.
+	failed error
+	closed bool
+	// done is closed by Close, and stopped is closed once the background flushes stopped.
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewFileStore returns a new store that persists Messages in dir, and generates their IDs with ids. dir is created if it does not exist.
+// Each change is appended to a checksummed write-ahead log before it is applied in memory, and the log is flushed to disk according to policy.
+// The last snapshot is loaded and the log is replayed when the store is opened. Records at the end of the log that were not completely written are discarded.
+func NewFileStore(dir string, ids idgen.Generator, policy SyncPolicy) (FileStore, error) {
+	if _, err := ParseSyncPolicy(string(policy)); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	fs := &fileStore{
+		mem:     newTempStore(ids),
+		ids:     ids,
+		dir:     dir,
+		policy:  policy,
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	if err := fs.load(); err != nil {
+		return nil, err
+	}
+	if policy == SyncInterval {
+		go fs.syncPeriodically()
+	} else {
+		close(fs.stopped)
+	}
+	return fs, nil
+}
+
+func (fs *fileStore) Create(ctx context.Context, p MessagePayload) (Message, error) {
+	msg := newMessage(fs.ids.New(), p, time.Now().UTC(), 1)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	err := fs.commit(record{
+		Op:       opPut,
+		ID:       msg.ID,
+		Message:  &msg,
+		Revision: &Revision{Message: msg, RevisedAt: msg.CreatedAt},
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func (fs *fileStore) Read(ctx context.Context, id string) (Message, error) {
+	return fs.mem.Read(ctx, id)
+}
+
+func (fs *fileStore) ReadAt(ctx context.Context, id string, at time.Time) (Message, error) {
+	return fs.mem.ReadAt(ctx, id, at)
+}
+
+func (fs *fileStore) Update(ctx context.Context, id string, p MessagePayload, version *int) (Message, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	old, err := fs.mem.Read(ctx, id)
+	if err != nil {
+		return Message{}, err
+	}
+	if version != nil && old.Version != *version {
+		return Message{}, ErrVersionMismatch
+	}
+	msg := newMessage(id, p, old.CreatedAt, old.Version+1)
+	msg.UpdatedAt = time.Now().UTC()
+	err = fs.commit(record{
+		Op:       opPut,
+		ID:       id,
+		Message:  &msg,
+		Revision: &Revision{Message: msg, RevisedAt: msg.UpdatedAt},
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func (fs *fileStore) List(ctx context.Context, p ListPayload) (Page, error) {
+	return fs.mem.List(ctx, p)
+}
+
+func (fs *fileStore) Delete(ctx context.Context, id string, version *int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	old, err := fs.mem.Read(ctx, id)
+	if err != nil {
+		return err
+	}
+	if version != nil && old.Version != *version {
+		return ErrVersionMismatch
+	}
+	msg := old
+	msg.DeletedAt = time.Now().UTC()
+	return fs.commit(record{
+		Op:       opTrash,
+		ID:       id,
+		Message:  &msg,
+		Revision: &Revision{Message: old, RevisedAt: msg.DeletedAt, Deleted: true},
+	})
+}
+
+func (fs *fileStore) Trash(ctx context.Context) ([]Message, error) {
+	return fs.mem.Trash(ctx)
+}
+
+func (fs *fileStore) Restore(ctx context.Context, id string) (Message, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	msg, ok := fs.mem.trashed(id)
+	if !ok {
+		return Message{}, ErrNotFound
+	}
+	msg.DeletedAt = time.Time{}
+	msg.Version++
+	err := fs.commit(record{
+		Op:       opPut,
+		ID:       id,
+		Message:  &msg,
+		Revision: &Revision{Message: msg, RevisedAt: time.Now().UTC()},
+	})
+	if err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func (fs *fileStore) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	return fs.mem.Revisions(ctx, id)
+}
+
+func (fs *fileStore) Purge(ctx context.Context, id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if !fs.mem.has(id) {
+		return ErrNotFound
+	}
+	return fs.commit(record{Op: opPurge, ID: id})
+}
+
+func (fs *fileStore) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	msgs, err := fs.mem.Trash(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var recs []record
+	for _, msg := range msgs {
+		if msg.DeletedAt.Before(before) {
+			recs = append(recs, record{Op: opExpire, ID: msg.ID})
+		}
+	}
+	if len(recs) == 0 {
+		return 0, nil
+	}
+	if err := fs.commit(recs...); err != nil {
+		return 0, err
+	}
+	return len(recs), nil
+}
+
+// Compact writes a snapshot of the next generation, and starts an empty write-ahead log.
+// The snapshot replaces the files of the previous generation once it is renamed, so a compaction that is interrupted leaves them as they were.
+func (fs *fileStore) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.closed {
+		return ErrClosed
+	}
+	if fs.failed != nil {
+		return fs.failed
+	}
+	if fs.size == 0 {
+		return nil
+	}
+	gen := fs.gen + 1
+	tmp := fs.path(snapshotTemp)
+	if err := writeSnapshot(tmp, fs.mem.snapshot()); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	wal, err := openWAL(fs.path(fileName(walPrefix, gen)))
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, fs.path(fileName(snapshotPrefix, gen))); err != nil {
+		wal.Close()
+		os.Remove(wal.Name())
+		os.Remove(tmp)
+		return err
+	}
+	if err := syncDir(fs.dir); err != nil {
+		// The rename may not be on disk, so records appended to either log may be lost.
+		wal.Close()
+		fs.failed = err
+		return err
+	}
+	old := fs.wal
+	fs.wal, fs.gen, fs.size, fs.dirty = wal, gen, 0, false
+	// The files of the previous generation are ignored and removed when the store is opened, so errors are not returned.
+	old.Close()
+	os.Remove(old.Name())
+	os.Remove(fs.path(fileName(snapshotPrefix, gen-1)))
+	return nil
+}
+
+// Close flushes and closes the write-ahead log. Messages can still be read from memory afterwards.
+func (fs *fileStore) Close() error {
+	fs.mu.Lock()
+	if fs.closed {
+		fs.mu.Unlock()
+		return ErrClosed
+	}
+	fs.closed = true
+	close(fs.done)
+	fs.mu.Unlock()
+	<-fs.stopped
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	err := fs.wal.Sync()
+	if cerr := fs.wal.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// commit appends recs to the write-ahead log, and applies them once they are written. fs.mu must be held.
+func (fs *fileStore) commit(recs ...record) error {
+	if fs.closed {
+		return ErrClosed
+	}
+	if fs.failed != nil {
+		return fs.failed
+	}
+	var b []byte
+	for _, rec := range recs {
+		encoded, err := encodeRecord(rec)
+		if err != nil {
+			return err
+		}
+		b = append(b, encoded...)
+	}
+	if _, err := fs.wal.Write(b); err != nil {
+		// A partially written record would hide the records appended after it from replay.
+		if terr := fs.wal.Truncate(fs.size); terr != nil {
+			fs.failed = terr
+		}
+		return err
+	}
+	fs.size += int64(len(b))
+	switch fs.policy {
+	case SyncAlways:
+		if err := fs.wal.Sync(); err != nil {
+			fs.failed = err
+			return err
+		}
+	case SyncInterval:
+		fs.dirty = true
+	}
+	for _, rec := range recs {
+		fs.mem.apply(rec)
+	}
+	return nil
+}
+
+// syncPeriodically flushes the write-ahead log every syncInterval if records were appended, until the store is closed.
+func (fs *fileStore) syncPeriodically() {
+	defer close(fs.stopped)
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-fs.done:
+			return
+		case <-ticker.C:
+			fs.mu.Lock()
+			if fs.dirty && fs.failed == nil {
+				if err := fs.wal.Sync(); err != nil {
+					fs.failed = err
+				}
+				fs.dirty = false
+			}
+			fs.mu.Unlock()
+		}
+	}
+}
+
+// load loads the snapshot of the last generation in dir, replays its write-ahead log, and removes the files of other generations.
+func (fs *fileStore) load() error {
+	infos, err := ioutil.ReadDir(fs.dir)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, info := range infos {
+		if gen, ok := parseGeneration(info.Name(), snapshotPrefix); ok && (!found || gen > fs.gen) {
+			fs.gen, found = gen, true
+		}
+	}
+	if found {
+		if err := fs.loadSnapshot(); err != nil {
+			return err
+		}
+	}
+	if err := fs.replay(); err != nil {
+		return err
+	}
+	for _, info := range infos {
+		name := info.Name()
+		if name == fileName(snapshotPrefix, fs.gen) || name == fileName(walPrefix, fs.gen) {
+			continue
+		}
+		_, isSnapshot := parseGeneration(name, snapshotPrefix)
+		_, isWAL := parseGeneration(name, walPrefix)
+		if isSnapshot || isWAL || name == snapshotTemp {
+			if err := os.Remove(fs.path(name)); err != nil {
+				return err
+			}
+		}
+	}
+	fs.wal, err = openWAL(fs.path(fileName(walPrefix, fs.gen)))
+	return err
+}
+
+func (fs *fileStore) loadSnapshot() error {
+	f, err := os.Open(fs.path(fileName(snapshotPrefix, fs.gen)))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = readRecords(f, fs.mem.apply)
+	if err == errInvalidRecord {
+		// Snapshots are flushed before they are renamed, so they are never partially written.
+		return ErrCorrupt
+	}
+	return err
+}
+
+// replay applies the records of the write-ahead log, and truncates it after the last valid record.
+func (fs *fileStore) replay() error {
+	name := fs.path(fileName(walPrefix, fs.gen))
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	n, err := readRecords(f, fs.mem.apply)
+	f.Close()
+	if err == errInvalidRecord {
+		err = os.Truncate(name, n)
+	}
+	if err != nil {
+		return err
+	}
+	fs.size = n
+	return nil
+}
+
+func (fs *fileStore) path(name string) string {
+	return filepath.Join(fs.dir, name)
+}
+
+// apply applies rec to ts. Records are only appended for valid changes, so they are not validated again.
+func (ts *tempStore) apply(rec record) {
+	sh := ts.shard(rec.ID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	switch rec.Op {
+	case opPut:
+		// Updated and restored Messages keep their position in the insertion order.
+		e, ok := sh.messages[rec.ID]
+		if !ok {
+			e, ok = sh.trash[rec.ID]
+		}
+		if !ok {
+			e.seq = atomic.AddUint64(&ts.seq, 1)
+		}
+		e.msg = *rec.Message
+		delete(sh.trash, rec.ID)
+		sh.messages[rec.ID] = e
+	case opTrash:
+		e := sh.messages[rec.ID]
+		e.msg = *rec.Message
+		delete(sh.messages, rec.ID)
+		sh.trash[rec.ID] = e
+	case opExpire:
+		delete(sh.trash, rec.ID)
+		delete(sh.revisions, rec.ID)
+	case opPurge:
+		delete(sh.messages, rec.ID)
+		delete(sh.trash, rec.ID)
+		delete(sh.revisions, rec.ID)
+	case opLoad:
+		if rec.Message != nil {
+			e := entry{*rec.Message, atomic.AddUint64(&ts.seq, 1)}
+			if rec.Trashed {
+				sh.trash[rec.ID] = e
+			} else {
+				sh.messages[rec.ID] = e
+			}
+		}
+		sh.revisions[rec.ID] = rec.Revisions
+	}
+	if rec.Revision != nil {
+		sh.revisions[rec.ID] = append(sh.revisions[rec.ID], *rec.Revision)
+	}
+}
+
+// snapshot returns a record of opLoad per Message of ts in insertion order, followed by the histories of the Messages emptied from the trash.
+func (ts *tempStore) snapshot() []record {
+	type loaded struct {
+		rec record
+		seq uint64
+	}
+	var msgs []loaded
+	var histories []record
+	for _, sh := range ts.shards {
+		sh.mu.RLock()
+		for id, revs := range sh.revisions {
+			rec := record{Op: opLoad, ID: id, Revisions: revs}
+			if e, ok := sh.messages[id]; ok {
+				msg := e.msg
+				rec.Message = &msg
+				msgs = append(msgs, loaded{rec, e.seq})
+			} else if e, ok := sh.trash[id]; ok {
+				msg := e.msg
+				rec.Message, rec.Trashed = &msg, true
+				msgs = append(msgs, loaded{rec, e.seq})
+			} else {
+				histories = append(histories, rec)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+	sort.Slice(msgs, func(i, j int) bool {
+		return msgs[i].seq < msgs[j].seq
+	})
+	recs := make([]record, 0, len(msgs)+len(histories))
+	for _, l := range msgs {
+		recs = append(recs, l.rec)
+	}
+	return append(recs, histories...)
+}
+
+// trashed returns the Message with id if it is in the trash.
+func (ts *tempStore) trashed(id string) (Message, bool) {
+	sh := ts.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	e, ok := sh.trash[id]
+	return e.msg, ok
+}
+
+// has returns true if ts has the Message with id, in the trash or not, or its history.
+func (ts *tempStore) has(id string) bool {
+	sh := ts.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	_, inMessages := sh.messages[id]
+	_, inTrash := sh.trash[id]
+	_, inRevisions := sh.revisions[id]
+	return inMessages || inTrash || inRevisions
+}
+
+// encodeRecord returns the length and CRC-32C checksum of the JSON encoding of rec, followed by the encoding.
+func encodeRecord(rec record) ([]byte, error) {
+	payload, err := json.Marshal(rec)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) > maxRecordSize {
+		return nil, errRecordTooLarge
+	}
+	b := make([]byte, recordHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(b[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(b[4:recordHeaderSize], crc32.Checksum(payload, crcTable))
+	copy(b[recordHeaderSize:], payload)
+	return b, nil
+}
+
+// readRecords applies the records read from r, and returns the length of the valid records.
+// errInvalidRecord is returned if a record is truncated or does not match its checksum, and the records after it are not read.
+func readRecords(r io.Reader, apply func(record)) (int64, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, recordHeaderSize)
+	var n int64
+	for {
+		_, err := io.ReadFull(br, header)
+		if err == io.EOF {
+			return n, nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			return n, errInvalidRecord
+		}
+		if err != nil {
+			return n, err
+		}
+		size := binary.BigEndian.Uint32(header[:4])
+		if size > maxRecordSize {
+			return n, errInvalidRecord
+		}
+		payload := make([]byte, size)
+		_, err = io.ReadFull(br, payload)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return n, errInvalidRecord
+		}
+		if err != nil {
+			return n, err
+		}
+		if crc32.Checksum(payload, crcTable) != binary.BigEndian.Uint32(header[4:]) {
+			return n, errInvalidRecord
+		}
+		var rec record
+		if err := json.Unmarshal(payload, &rec); err != nil {
+			return n, errInvalidRecord
+		}
+		apply(rec)
+		n += recordHeaderSize + int64(size)
+	}
+}
+
+// writeSnapshot writes recs to the file name, and flushes it.
+func writeSnapshot(name string, recs []record) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	w := bufio.NewWriter(f)
+	for _, rec := range recs {
+		b, err := encodeRecord(rec)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func openWAL(name string) (*os.File, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+}
+
+// syncDir flushes the entries of dir, so the files created and renamed in it are on disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	err = d.Sync()
+	if cerr := d.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func fileName(prefix string, gen uint64) string {
+	return prefix + strconv.FormatUint(gen, 10)
+}
+
+// parseGeneration returns the generation of the file name if it has prefix.
+func parseGeneration(name, prefix string) (uint64, bool) {
+	if !strings.HasPrefix(name, prefix) {
+		return 0, false
+	}
+	gen, err := strconv.ParseUint(strings.TrimPrefix(name, prefix), 10, 64)
+	return gen, err == nil
+}
@@ -0,0 +1,372 @@
+package store
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nicholaslam/example-service/internal/idgen"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// state is everything a Store returns, which must be the same after it is reopened.
+type state struct {
+	messages  []Message
+	trash     []Message
+	revisions map[string][]Revision
+}
+
+func stateOf(t *testing.T, s Store, ids []string) state {
+	page, err := s.List(context.Background(), ListPayload{})
+	require.NoError(t, err)
+	trash, err := s.Trash(context.Background())
+	require.NoError(t, err)
+	revisions := map[string][]Revision{}
+	for _, id := range ids {
+		revs, err := s.Revisions(context.Background(), id)
+		if err == ErrNotFound {
+			continue
+		}
+		require.NoError(t, err)
+		revisions[id] = revs
+	}
+	return state{page.Messages, trash, revisions}
+}
+
+// populate makes every kind of change to s, and returns the IDs of the Messages it created.
+func populate(t *testing.T, s Store) []string {
+	ctx := context.Background()
+	var ids []string
+	for _, text := range []string{"racecar", "a toyota", "kayak", "abc", "xyz"} {
+		msg, err := s.Create(ctx, MessagePayload{Text: text, Length: len(text)})
+		require.NoError(t, err)
+		ids = append(ids, msg.ID)
+	}
+	_, err := s.Update(ctx, ids[1], MessagePayload{Text: "a toyota's a toyota"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ctx, ids[2], nil))
+	require.NoError(t, s.Delete(ctx, ids[3], nil))
+	_, err = s.Restore(ctx, ids[3])
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ctx, ids[4], nil))
+	n, err := s.EmptyTrash(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	require.NoError(t, s.Purge(ctx, ids[0]))
+	msg, err := s.Create(ctx, MessagePayload{Text: "level"})
+	require.NoError(t, err)
+	require.NoError(t, s.Delete(ctx, msg.ID, nil))
+	return append(ids, msg.ID)
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "filestore")
+	require.NoError(t, err)
+	return dir
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestParseSyncPolicy(t *testing.T) {
+	testCases := []struct {
+		name string
+		want SyncPolicy
+		err  error
+	}{
+		{"always", SyncAlways, nil},
+		{"interval", SyncInterval, nil},
+		{"never", SyncNever, nil},
+		{"", "", ErrInvalidSyncPolicy},
+		{"sometimes", "", ErrInvalidSyncPolicy},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := ParseSyncPolicy(tc.name)
+			require.Equal(t, tc.err, err)
+			require.Equal(t, tc.want, p)
+		})
+	}
+}
+
+func TestNewFileStoreInvalidSyncPolicy(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	_, err := NewFileStore(dir, idgen.NewULID(), "sometimes")
+	require.Equal(t, ErrInvalidSyncPolicy, err)
+}
+
+func TestFileStoreReopen(t *testing.T) {
+	testCases := []struct {
+		name    string
+		policy  SyncPolicy
+		compact bool
+	}{
+		{"always", SyncAlways, false},
+		{"interval", SyncInterval, false},
+		{"never", SyncNever, false},
+		{"compacted", SyncAlways, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+			fs, err := NewFileStore(dir, idgen.NewULID(), tc.policy)
+			require.NoError(t, err)
+			ids := populate(t, fs)
+			if tc.compact {
+				require.NoError(t, fs.Compact())
+				msg, err := fs.Create(context.Background(), MessagePayload{Text: "noon"})
+				require.NoError(t, err)
+				ids = append(ids, msg.ID)
+			}
+			want := stateOf(t, fs, ids)
+			require.NoError(t, fs.Close())
+
+			fs, err = NewFileStore(dir, idgen.NewULID(), tc.policy)
+			require.NoError(t, err)
+			defer fs.Close()
+			require.Equal(t, want, stateOf(t, fs, ids))
+		})
+	}
+}
+
+func TestFileStoreCompact(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fs, err := NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.NoError(t, err)
+	require.NoError(t, fs.Compact())
+	require.Equal(t, []string{"wal.0"}, dirNames(t, dir))
+
+	ids := populate(t, fs)
+	require.NoError(t, fs.Compact())
+	require.Equal(t, []string{"snapshot.1", "wal.1"}, dirNames(t, dir))
+	info, err := os.Stat(filepath.Join(dir, "wal.1"))
+	require.NoError(t, err)
+	require.Zero(t, info.Size())
+
+	_, err = fs.Update(context.Background(), ids[1], MessagePayload{Text: "abba"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, fs.Compact())
+	require.Equal(t, []string{"snapshot.2", "wal.2"}, dirNames(t, dir))
+	want := stateOf(t, fs, ids)
+	require.NoError(t, fs.Close())
+
+	fs, err = NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.NoError(t, err)
+	defer fs.Close()
+	require.Equal(t, want, stateOf(t, fs, ids))
+}
+
+func TestFileStoreInterruptedCompaction(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fs, err := NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.NoError(t, err)
+	ids := populate(t, fs)
+	want := stateOf(t, fs, ids)
+	require.NoError(t, fs.Close())
+
+	// A compaction that stopped before renaming its snapshot leaves a temporary snapshot and an empty log of the next generation.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "snapshot.tmp"), []byte("partial"), 0600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "wal.1"), nil, 0600))
+
+	fs, err = NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.NoError(t, err)
+	defer fs.Close()
+	require.Equal(t, want, stateOf(t, fs, ids))
+	require.Equal(t, []string{"wal.0"}, dirNames(t, dir))
+}
+
+func TestFileStoreTornWrite(t *testing.T) {
+	testCases := []struct {
+		name    string
+		corrupt func(b []byte) []byte
+		// lost is true if the corruption is in the last record that was appended.
+		lost bool
+	}{
+		{
+			"truncated payload",
+			func(b []byte) []byte {
+				return b[:len(b)-3]
+			},
+			true,
+		},
+		{
+			"truncated header",
+			func(b []byte) []byte {
+				return append(b, 0, 0, 1)
+			},
+			false,
+		},
+		{
+			"checksum mismatch",
+			func(b []byte) []byte {
+				b[len(b)-2] ^= 0xff
+				return b
+			},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := tempDir(t)
+			defer os.RemoveAll(dir)
+			fs, err := NewFileStore(dir, idgen.NewULID(), SyncAlways)
+			require.NoError(t, err)
+			kept, err := fs.Create(context.Background(), MessagePayload{Text: "racecar"})
+			require.NoError(t, err)
+			last, err := fs.Create(context.Background(), MessagePayload{Text: "kayak"})
+			require.NoError(t, err)
+			require.NoError(t, fs.Close())
+
+			name := filepath.Join(dir, "wal.0")
+			b, err := ioutil.ReadFile(name)
+			require.NoError(t, err)
+			require.NoError(t, ioutil.WriteFile(name, tc.corrupt(b), 0600))
+
+			fs, err = NewFileStore(dir, idgen.NewULID(), SyncAlways)
+			require.NoError(t, err)
+			_, err = fs.Read(context.Background(), kept.ID)
+			require.NoError(t, err)
+			_, err = fs.Read(context.Background(), last.ID)
+			if tc.lost {
+				require.Equal(t, ErrNotFound, err)
+			} else {
+				require.NoError(t, err)
+			}
+			// Records appended after the torn record was discarded are replayed.
+			created, err := fs.Create(context.Background(), MessagePayload{Text: "level"})
+			require.NoError(t, err)
+			require.NoError(t, fs.Close())
+
+			fs, err = NewFileStore(dir, idgen.NewULID(), SyncAlways)
+			require.NoError(t, err)
+			defer fs.Close()
+			_, err = fs.Read(context.Background(), created.ID)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestFileStoreCorruptSnapshot(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fs, err := NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.NoError(t, err)
+	populate(t, fs)
+	require.NoError(t, fs.Compact())
+	require.NoError(t, fs.Close())
+
+	name := filepath.Join(dir, "snapshot.1")
+	b, err := ioutil.ReadFile(name)
+	require.NoError(t, err)
+	b[len(b)/2] ^= 0xff
+	require.NoError(t, ioutil.WriteFile(name, b, 0600))
+
+	_, err = NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.Equal(t, ErrCorrupt, err)
+}
+
+func TestFileStoreClosed(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fs, err := NewFileStore(dir, idgen.NewULID(), SyncInterval)
+	require.NoError(t, err)
+	created, err := fs.Create(context.Background(), MessagePayload{Text: "racecar"})
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+
+	_, err = fs.Create(context.Background(), MessagePayload{Text: "kayak"})
+	require.Equal(t, ErrClosed, err)
+	require.Equal(t, ErrClosed, fs.Compact())
+	require.Equal(t, ErrClosed, fs.Close())
+	msg, err := fs.Read(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, created, msg)
+}
+
+func TestFileStoreErrors(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fs, err := NewFileStore(dir, idgen.NewULID(), SyncAlways)
+	require.NoError(t, err)
+	defer fs.Close()
+	ctx := context.Background()
+	created, err := fs.Create(ctx, MessagePayload{Text: "racecar"})
+	require.NoError(t, err)
+
+	_, err = fs.Update(ctx, "missing", MessagePayload{}, nil)
+	require.Equal(t, ErrNotFound, err)
+	_, err = fs.Update(ctx, created.ID, MessagePayload{}, toIntPointer(2))
+	require.Equal(t, ErrVersionMismatch, err)
+	require.Equal(t, ErrNotFound, fs.Delete(ctx, "missing", nil))
+	require.Equal(t, ErrVersionMismatch, fs.Delete(ctx, created.ID, toIntPointer(2)))
+	_, err = fs.Restore(ctx, created.ID)
+	require.Equal(t, ErrNotFound, err)
+	require.Equal(t, ErrNotFound, fs.Purge(ctx, "missing"))
+	n, err := fs.EmptyTrash(ctx, time.Now())
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+}
+
+func TestFileStoreConcurrent(t *testing.T) {
+	const workers = 16
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	fs, err := NewFileStore(dir, idgen.NewULID(), SyncNever)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var ids []string
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			msg, err := fs.Create(context.Background(), MessagePayload{Text: "racecar"})
+			if !assert.NoError(t, err) {
+				return
+			}
+			_, err = fs.Update(context.Background(), msg.ID, MessagePayload{Text: "kayak"}, nil)
+			assert.NoError(t, err)
+			if w%2 == 0 {
+				assert.NoError(t, fs.Delete(context.Background(), msg.ID, nil))
+			}
+			if w%4 == 0 {
+				assert.NoError(t, fs.Compact())
+			}
+			mu.Lock()
+			ids = append(ids, msg.ID)
+			mu.Unlock()
+		}(w)
+	}
+	wg.Wait()
+	want := stateOf(t, fs, ids)
+	require.Len(t, want.messages, workers/2)
+	require.Len(t, want.trash, workers/2)
+	require.NoError(t, fs.Close())
+
+	fs, err = NewFileStore(dir, idgen.NewULID(), SyncNever)
+	require.NoError(t, err)
+	defer fs.Close()
+	require.Equal(t, want, stateOf(t, fs, ids))
+}
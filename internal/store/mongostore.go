@@ -2,42 +2,50 @@ package store
 
 import (
 	"context"
+	"regexp"
 	"time"
 
 	"github.com/mongodb/mongo-go-driver/bson"
-	"github.com/mongodb/mongo-go-driver/bson/objectid"
 	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/mongodb/mongo-go-driver/mongo/findopt"
+	"github.com/mongodb/mongo-go-driver/mongo/mongoopt"
+	"github.com/nicholaslam/example-service/internal/idgen"
 )
 
 type mongoStore struct {
 	collection *mongo.Collection
+	revisions  *mongo.Collection
+	ids        idgen.Generator
 }
 
-// NewMongoStore returns a new store that persists Messages in MongoDB.
-func NewMongoStore(c *mongo.Collection) Store {
+// NewMongoStore returns a new store that persists Messages in the collection c and their history in the collection revisions in MongoDB, and generates their IDs with ids.
+// A Revision is inserted after the Message is written, so the history of a Message can lack its last change if inserting it fails.
+func NewMongoStore(c *mongo.Collection, revisions *mongo.Collection, ids idgen.Generator) Store {
 	return &mongoStore{
 		collection: c,
+		revisions:  revisions,
+		ids:        ids,
 	}
 }
 
 func (ms *mongoStore) Create(ctx context.Context, p MessagePayload) (Message, error) {
-	msg := Message{
-		ID:         objectid.New().Hex(),
-		Text:       p.Text,
-		Palindrome: p.Palindrome,
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
-	}
+	// CreatedAt is truncated like MongoDB truncates it, so it is the same when the Message is read.
+	msg := newMessage(ms.ids.New(), p, time.Now().UTC().Truncate(time.Millisecond), 1)
 	_, err := ms.collection.InsertOne(ctx, msg)
 	if err != nil {
 		return Message{}, err
 	}
+	_, err = ms.revisions.InsertOne(ctx, Revision{Message: msg, RevisedAt: msg.CreatedAt})
+	if err != nil {
+		return Message{}, err
+	}
 	return msg, nil
 }
 
 func (ms *mongoStore) Read(ctx context.Context, id string) (Message, error) {
-	filter := bson.NewDocument(bson.EC.String("_id", id))
+	filter := bson.NewDocument(bson.EC.String("_id", id), notDeleted())
 	var msg Message
-	err := ms.collection.FindOne(ctx, filter).Decode(&msg)
+	err := decodeMessage(ms.collection.FindOne(ctx, filter), &msg)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
 			return Message{}, ErrNotFound
@@ -47,20 +55,312 @@ func (ms *mongoStore) Read(ctx context.Context, id string) (Message, error) {
 	return msg, nil
 }
 
-func (ms *mongoStore) List(ctx context.Context, p ListPayload) ([]Message, error) {
-	var filter *bson.Document
-	if p.Palindrome != nil {
-		filter = bson.NewDocument(bson.EC.Boolean("palindrome", *p.Palindrome))
+func (ms *mongoStore) ReadAt(ctx context.Context, id string, at time.Time) (Message, error) {
+	revs, err := ms.Revisions(ctx, id)
+	if err != nil {
+		return Message{}, err
+	}
+	return revisionAt(revs, at)
+}
+
+// Update replaces the Message only if its version was not changed since it was read.
+// If version is nil, the Message is read again and the update is retried, and ErrVersionMismatch is returned otherwise.
+func (ms *mongoStore) Update(ctx context.Context, id string, p MessagePayload, version *int) (Message, error) {
+	for {
+		old, err := ms.Read(ctx, id)
+		if err != nil {
+			return Message{}, err
+		}
+		if version != nil && old.Version != *version {
+			return Message{}, ErrVersionMismatch
+		}
+		msg := newMessage(id, p, old.CreatedAt, old.Version+1)
+		msg.UpdatedAt = time.Now().UTC()
+		res, err := ms.collection.ReplaceOne(ctx, versionFilter(id, old.Version), msg)
+		if err != nil {
+			return Message{}, err
+		}
+		if res.MatchedCount == 0 {
+			if version != nil {
+				return Message{}, ErrVersionMismatch
+			}
+			continue
+		}
+		_, err = ms.revisions.InsertOne(ctx, Revision{Message: msg, RevisedAt: msg.UpdatedAt})
+		if err != nil {
+			return Message{}, err
+		}
+		return msg, nil
+	}
+}
+
+// versionFilter matches the Message with id and version unless it is in the trash. Messages stored before versions were introduced have version 0.
+func versionFilter(id string, version int) *bson.Document {
+	filter := bson.NewDocument(bson.EC.String("_id", id), notDeleted())
+	if version == 0 {
+		filter.Append(bson.EC.SubDocumentFromElements("version", bson.EC.ArrayFromElements("$in", bson.VC.Int64(0), bson.VC.Null())))
+	} else {
+		filter.Append(bson.EC.Int64("version", int64(version)))
+	}
+	return filter
+}
+
+func (ms *mongoStore) List(ctx context.Context, p ListPayload) (Page, error) {
+	filter, err := pageFilter(p)
+	if err != nil {
+		return Page{}, err
+	}
+	s := sortOf(p)
+	order := int32(1)
+	if p.Descending {
+		order = -1
+	}
+	opts := []findopt.Find{
+		findopt.Sort(bson.NewDocument(bson.EC.Int32(string(s), order), bson.EC.Int32("_id", order))),
+	}
+	if p.Limit > 0 {
+		// Find one more Message than the limit to know if there is a next Page.
+		opts = append(opts, findopt.Limit(int64(p.Limit)+1))
+	}
+	cur, err := ms.collection.Find(ctx, filter, opts...)
+	if err != nil {
+		return Page{}, err
 	}
-	cur, err := ms.collection.Find(ctx, filter)
 	defer cur.Close(ctx)
+	page := Page{Messages: []Message{}}
+	for cur.Next(ctx) {
+		var msg Message
+		err := decodeMessage(cur, &msg)
+		if err != nil {
+			return Page{}, err
+		}
+		if p.Limit > 0 && len(page.Messages) == p.Limit {
+			page.Next = newCursor(page.Messages[len(page.Messages)-1], s, p.Descending).String()
+			break
+		}
+		page.Messages = append(page.Messages, msg)
+	}
+	return page, nil
+}
+
+// pageFilter matches the Messages of the Page listed with p, which follow its cursor.
+func pageFilter(p ListPayload) (*bson.Document, error) {
+	filter := listFilter(p)
+	if p.Cursor != "" {
+		c, err := parseCursor(p.Cursor, p)
+		if err != nil {
+			return nil, err
+		}
+		filter.Append(cursorFilter(c))
+	}
+	return filter, nil
+}
+
+// cursorFilter matches the Messages that follow c in its sort order.
+func cursorFilter(c cursor) *bson.Element {
+	op := "$gt"
+	if c.Descending {
+		op = "$lt"
+	}
+	var value *bson.Value
+	switch c.Sort {
+	case SortCreatedAt:
+		// The time was validated by parseCursor.
+		createdAt, _ := time.Parse(TimeLayout, c.Text)
+		value = bson.VC.DateTime(createdAt.UnixNano() / int64(time.Millisecond))
+	case SortLength:
+		value = bson.VC.Int64(int64(c.Length))
+	default:
+		value = bson.VC.String(c.Text)
+	}
+	return bson.EC.ArrayFromElements("$or",
+		bson.VC.DocumentFromElements(bson.EC.SubDocumentFromElements(string(c.Sort), bson.EC.Interface(op, value))),
+		bson.VC.DocumentFromElements(
+			bson.EC.Interface(string(c.Sort), value),
+			bson.EC.SubDocumentFromElements("_id", bson.EC.String(op, c.ID)),
+		),
+	)
+}
+
+// decoder is implemented by mongo.Cursor and mongo.DocumentResult.
+type decoder interface {
+	Decode(v interface{}) error
+}
+
+// timeField is a time at the path key of a document, which is decoded into t.
+type timeField struct {
+	key []string
+	t   *time.Time
+}
+
+// decodeMessage decodes a Message from dec.
+func decodeMessage(dec decoder, msg *Message) error {
+	return decodeWithTime(dec, msg,
+		timeField{[]string{"createdAt"}, &msg.CreatedAt},
+		timeField{[]string{"updatedAt"}, &msg.UpdatedAt},
+		timeField{[]string{"deletedAt"}, &msg.DeletedAt},
+	)
+}
+
+// decodeRevision decodes a Revision from dec.
+func decodeRevision(dec decoder, rev *Revision) error {
+	return decodeWithTime(dec, rev,
+		timeField{[]string{"message", "createdAt"}, &rev.Message.CreatedAt},
+		timeField{[]string{"message", "updatedAt"}, &rev.Message.UpdatedAt},
+		timeField{[]string{"message", "deletedAt"}, &rev.Message.DeletedAt},
+		timeField{[]string{"revisedAt"}, &rev.RevisedAt},
+	)
+}
+
+// decodeWithTime decodes v from dec, except for the times of fields, which are decoded into their targets.
+// The driver cannot decode a datetime into a time.Time, so it is decoded from the document.
+// A time that was not migrated from a string yet is parsed from the string.
+func decodeWithTime(dec decoder, v interface{}, fields ...timeField) error {
+	doc := bson.NewDocument()
+	if err := dec.Decode(doc); err != nil {
+		return err
+	}
+	times := make([]time.Time, len(fields))
+	for i, f := range fields {
+		elem := doc.Delete(f.key...)
+		if elem == nil {
+			continue
+		}
+		if dt, ok := elem.Value().TimeOK(); ok {
+			times[i] = dt.UTC()
+		} else if s, ok := elem.Value().StringValueOK(); ok {
+			t, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return err
+			}
+			times[i] = t
+		}
+	}
+	b, err := doc.MarshalBSON()
+	if err != nil {
+		return err
+	}
+	if err := bson.Unmarshal(b, v); err != nil {
+		return err
+	}
+	for i, f := range fields {
+		*f.t = times[i]
+	}
+	return nil
+}
+
+// deletedBefore matches the Messages that were moved to the trash before a point in time.
+func deletedBefore(before time.Time) *bson.Element {
+	return bson.EC.SubDocumentFromElements("deletedAt", bson.EC.Time("$lt", before))
+}
+
+// in matches the documents whose field at key is one of values.
+func in(key string, values []*bson.Value) *bson.Element {
+	return bson.EC.SubDocumentFromElements(key, bson.EC.ArrayFromElements("$in", values...))
+}
+
+// notDeleted matches the Messages that are not in the trash.
+func notDeleted() *bson.Element {
+	return bson.EC.SubDocumentFromElements("deletedAt", bson.EC.Boolean("$exists", false))
+}
+
+// deleted matches the Messages that are in the trash.
+func deleted() *bson.Element {
+	return bson.EC.SubDocumentFromElements("deletedAt", bson.EC.Boolean("$exists", true))
+}
+
+func listFilter(p ListPayload) *bson.Document {
+	filter := bson.NewDocument(notDeleted())
+	if p.Palindrome != nil {
+		filter.Append(bson.EC.Boolean("palindrome", *p.Palindrome))
+	}
+	if p.MinLongestPalindrome != nil {
+		filter.Append(bson.EC.SubDocumentFromElements("longestPalindrome.length", bson.EC.Int64("$gte", int64(*p.MinLongestPalindrome))))
+	}
+	if p.MaxDistance != nil {
+		filter.Append(bson.EC.SubDocumentFromElements("distance", bson.EC.Int64("$lte", int64(*p.MaxDistance))))
+	}
+	if p.Rearrangeable != nil {
+		filter.Append(bson.EC.Boolean("rearrangeable", *p.Rearrangeable))
+	}
+	if p.Base != nil {
+		// Matches the messages whose bases array contains the base.
+		filter.Append(bson.EC.Int64("bases", int64(*p.Base)))
+	}
+	var createdAt []*bson.Element
+	if p.CreatedAfter != nil {
+		createdAt = append(createdAt, bson.EC.Time("$gt", *p.CreatedAfter))
+	}
+	if p.CreatedBefore != nil {
+		createdAt = append(createdAt, bson.EC.Time("$lt", *p.CreatedBefore))
+	}
+	if len(createdAt) > 0 {
+		filter.Append(bson.EC.SubDocumentFromElements("createdAt", createdAt...))
+	}
+	// Both text patterns are matched against the same field, so they are combined with $and.
+	var text []*bson.Value
+	if p.Prefix != nil {
+		text = append(text, bson.VC.DocumentFromElements(bson.EC.Regex("text", "^"+regexp.QuoteMeta(*p.Prefix), "")))
+	}
+	if p.Contains != nil {
+		text = append(text, bson.VC.DocumentFromElements(bson.EC.Regex("text", regexp.QuoteMeta(*p.Contains), "")))
+	}
+	if len(text) > 0 {
+		filter.Append(bson.EC.ArrayFromElements("$and", text...))
+	}
+	var length []*bson.Element
+	if p.MinLength != nil {
+		length = append(length, bson.EC.Int64("$gte", int64(*p.MinLength)))
+	}
+	if p.MaxLength != nil {
+		length = append(length, bson.EC.Int64("$lte", int64(*p.MaxLength)))
+	}
+	if len(length) > 0 {
+		filter.Append(bson.EC.SubDocumentFromElements("length", length...))
+	}
+	return filter
+}
+
+func (ms *mongoStore) Delete(ctx context.Context, id string, version *int) error {
+	filter := bson.NewDocument(bson.EC.String("_id", id), notDeleted())
+	if version != nil {
+		filter = versionFilter(id, *version)
+	}
+	deletedAt := time.Now().UTC()
+	update := bson.NewDocument(bson.EC.SubDocumentFromElements("$set", bson.EC.Time("deletedAt", deletedAt)))
+	var msg Message
+	err := decodeMessage(ms.collection.FindOneAndUpdate(ctx, filter, update), &msg)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// The filter does not tell a missing Message from one with a different version.
+			if version != nil {
+				if _, err := ms.Read(ctx, id); err != nil {
+					return err
+				}
+				return ErrVersionMismatch
+			}
+			return ErrNotFound
+		}
+		return err
+	}
+	_, err = ms.revisions.InsertOne(ctx, Revision{
+		Message:   msg,
+		RevisedAt: deletedAt,
+		Deleted:   true,
+	})
+	return err
+}
+
+func (ms *mongoStore) Trash(ctx context.Context) ([]Message, error) {
+	cur, err := ms.collection.Find(ctx, bson.NewDocument(deleted()))
 	if err != nil {
 		return []Message{}, err
 	}
+	defer cur.Close(ctx)
 	msgs := []Message{}
 	for cur.Next(ctx) {
 		var msg Message
-		err := cur.Decode(&msg)
+		err := decodeMessage(cur, &msg)
 		if err != nil {
 			return []Message{}, err
 		}
@@ -69,15 +369,144 @@ func (ms *mongoStore) List(ctx context.Context, p ListPayload) ([]Message, error
 	return msgs, nil
 }
 
-func (ms *mongoStore) Delete(ctx context.Context, id string) error {
-	filter := bson.NewDocument(bson.EC.String("_id", id))
+func (ms *mongoStore) Restore(ctx context.Context, id string) (Message, error) {
+	filter := bson.NewDocument(bson.EC.String("_id", id), deleted())
+	update := bson.NewDocument(
+		bson.EC.SubDocumentFromElements("$unset", bson.EC.String("deletedAt", "")),
+		bson.EC.SubDocumentFromElements("$inc", bson.EC.Int64("version", 1)),
+	)
 	var msg Message
-	err := ms.collection.FindOneAndDelete(ctx, filter).Decode(&msg)
+	err := decodeMessage(ms.collection.FindOneAndUpdate(ctx, filter, update, findopt.ReturnDocument(mongoopt.After)), &msg)
 	if err != nil {
 		if err == mongo.ErrNoDocuments {
-			return nil
+			return Message{}, ErrNotFound
+		}
+		return Message{}, err
+	}
+	_, err = ms.revisions.InsertOne(ctx, Revision{Message: msg, RevisedAt: time.Now().UTC()})
+	if err != nil {
+		return Message{}, err
+	}
+	return msg, nil
+}
+
+func (ms *mongoStore) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	filter := bson.NewDocument(bson.EC.String("message._id", id))
+	cur, err := ms.revisions.Find(ctx, filter)
+	if err != nil {
+		return []Revision{}, err
+	}
+	defer cur.Close(ctx)
+	revs := []Revision{}
+	for cur.Next(ctx) {
+		var rev Revision
+		err := decodeRevision(cur, &rev)
+		if err != nil {
+			return []Revision{}, err
 		}
+		revs = append(revs, rev)
+	}
+	if len(revs) == 0 {
+		return []Revision{}, ErrNotFound
+	}
+	sortRevisions(revs)
+	return revs, nil
+}
+
+func (ms *mongoStore) Purge(ctx context.Context, id string) error {
+	res, err := ms.collection.DeleteOne(ctx, bson.NewDocument(bson.EC.String("_id", id)))
+	if err != nil {
+		return err
+	}
+	revsRes, err := ms.revisions.DeleteMany(ctx, bson.NewDocument(bson.EC.String("message._id", id)))
+	if err != nil {
 		return err
 	}
+	if res.DeletedCount == 0 && revsRes.DeletedCount == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
+
+// emptyTrashBatchSize is the number of expired Messages EmptyTrash deletes at a time.
+const emptyTrashBatchSize = 1000
+
+// EmptyTrash only reads the IDs of the expired Messages, which are needed to delete their history, and deletes them in batches.
+func (ms *mongoStore) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	cur, err := ms.collection.Find(ctx, bson.NewDocument(deletedBefore(before)), findopt.Projection(bson.NewDocument(bson.EC.Int32("_id", 1))))
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+	n := 0
+	var ids []*bson.Value
+	for cur.Next(ctx) {
+		doc := bson.NewDocument()
+		if err := cur.Decode(doc); err != nil {
+			return n, err
+		}
+		ids = append(ids, doc.Lookup("_id"))
+		if len(ids) == emptyTrashBatchSize {
+			deleted, err := ms.expire(ctx, ids, before)
+			n += deleted
+			if err != nil {
+				return n, err
+			}
+			ids = ids[:0]
+		}
+	}
+	if err := cur.Err(); err != nil {
+		return n, err
+	}
+	if len(ids) == 0 {
+		return n, nil
+	}
+	deleted, err := ms.expire(ctx, ids, before)
+	return n + deleted, err
+}
+
+// expire deletes the Messages with ids that are still in the trash since before a point in time, and the history of those that were deleted.
+func (ms *mongoStore) expire(ctx context.Context, ids []*bson.Value, before time.Time) (int, error) {
+	res, err := ms.collection.DeleteMany(ctx, bson.NewDocument(in("_id", ids), deletedBefore(before)))
+	if err != nil {
+		return 0, err
+	}
+	if int(res.DeletedCount) < len(ids) {
+		// Messages that were restored in the meantime keep their history.
+		ids, err = ms.missing(ctx, ids)
+		if err != nil {
+			return int(res.DeletedCount), err
+		}
+	}
+	if len(ids) > 0 {
+		_, err = ms.revisions.DeleteMany(ctx, bson.NewDocument(in("message._id", ids)))
+	}
+	return int(res.DeletedCount), err
+}
+
+// missing returns the ids that no Message has, whether it is in the trash or not.
+func (ms *mongoStore) missing(ctx context.Context, ids []*bson.Value) ([]*bson.Value, error) {
+	cur, err := ms.collection.Find(ctx, bson.NewDocument(in("_id", ids)), findopt.Projection(bson.NewDocument(bson.EC.Int32("_id", 1))))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	found := map[string]bool{}
+	for cur.Next(ctx) {
+		doc := bson.NewDocument()
+		if err := cur.Decode(doc); err != nil {
+			return nil, err
+		}
+		found[doc.Lookup("_id").StringValue()] = true
+	}
+	if err := cur.Err(); err != nil {
+		return nil, err
+	}
+	var res []*bson.Value
+	for _, id := range ids {
+		if !found[id.StringValue()] {
+			res = append(res, id)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,275 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/bson"
+	"github.com/stretchr/testify/require"
+)
+
+// rawDecoder decodes a BSON document like mongo.DocumentResult.
+type rawDecoder []byte
+
+func (rd rawDecoder) Decode(v interface{}) error {
+	return bson.Unmarshal(rd, v)
+}
+
+func TestDecodeMessage(t *testing.T) {
+	createdAt := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		createdAt *bson.Element
+		want      time.Time
+		errMsg    string
+	}{
+		{
+			"datetime",
+			bson.EC.Time("createdAt", createdAt),
+			createdAt,
+			"",
+		},
+		{
+			"string",
+			bson.EC.String("createdAt", "2018-06-01T12:00:00Z"),
+			createdAt,
+			"",
+		},
+		{
+			"invalid string",
+			bson.EC.String("createdAt", "invalid"),
+			time.Time{},
+			`parsing time "invalid" as "2006-01-02T15:04:05.999999999Z07:00": cannot parse "invalid" as "2006"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := bson.NewDocument(bson.EC.String("_id", "123"), bson.EC.String("text", "racecar"), tc.createdAt)
+			b, err := doc.MarshalBSON()
+			require.NoError(t, err)
+			var msg Message
+			err = decodeMessage(rawDecoder(b), &msg)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, "123", msg.ID)
+				require.Equal(t, "racecar", msg.Text)
+				require.Equal(t, tc.want, msg.CreatedAt)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestDecodeMessageDeletedAt(t *testing.T) {
+	deletedAt := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		deletedAt *bson.Element
+		want      time.Time
+	}{
+		{
+			"datetime",
+			bson.EC.Time("deletedAt", deletedAt),
+			deletedAt,
+		},
+		{
+			"string",
+			bson.EC.String("deletedAt", "2018-06-01T12:00:00.000000000Z"),
+			deletedAt,
+		},
+		{
+			"missing",
+			nil,
+			time.Time{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			doc := bson.NewDocument(bson.EC.String("_id", "123"), bson.EC.Time("createdAt", deletedAt))
+			if tc.deletedAt != nil {
+				doc.Append(tc.deletedAt)
+			}
+			b, err := doc.MarshalBSON()
+			require.NoError(t, err)
+			var msg Message
+			require.NoError(t, decodeMessage(rawDecoder(b), &msg))
+			require.Equal(t, deletedAt, msg.CreatedAt)
+			require.Equal(t, tc.want, msg.DeletedAt)
+		})
+	}
+}
+
+func TestDecodeRevision(t *testing.T) {
+	createdAt := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	doc := bson.NewDocument(
+		bson.EC.SubDocumentFromElements("message", bson.EC.String("_id", "123"), bson.EC.Time("createdAt", createdAt), bson.EC.Time("updatedAt", createdAt)),
+		bson.EC.String("revisedAt", "2018-06-01T12:00:00.000000000Z"),
+	)
+	b, err := doc.MarshalBSON()
+	require.NoError(t, err)
+	var rev Revision
+	require.NoError(t, decodeRevision(rawDecoder(b), &rev))
+	require.Equal(t, "123", rev.Message.ID)
+	require.Equal(t, createdAt, rev.Message.CreatedAt)
+	require.Equal(t, createdAt, rev.Message.UpdatedAt)
+	require.Equal(t, createdAt, rev.RevisedAt)
+}
+
+func TestPageFilter(t *testing.T) {
+	createdAt := time.Date(2018, 6, 1, 12, 0, 0, 0, time.UTC)
+	ms := func(t time.Time) int64 {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	notDeleted := bson.EC.SubDocumentFromElements("deletedAt", bson.EC.Boolean("$exists", false))
+
+	testCases := []struct {
+		name    string
+		payload ListPayload
+		want    *bson.Document
+		err     error
+	}{
+		{
+			"empty",
+			ListPayload{},
+			bson.NewDocument(notDeleted),
+			nil,
+		},
+		{
+			"createdAfter and createdBefore",
+			ListPayload{
+				CreatedAfter:  toTimePointer(createdAt),
+				CreatedBefore: toTimePointer(createdAt.Add(time.Hour)),
+			},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.SubDocumentFromElements("createdAt",
+					bson.EC.DateTime("$gt", ms(createdAt)),
+					bson.EC.DateTime("$lt", ms(createdAt.Add(time.Hour))),
+				),
+			),
+			nil,
+		},
+		{
+			"prefix",
+			ListPayload{Prefix: toStringPointer("a.b")},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.ArrayFromElements("$and",
+					bson.VC.DocumentFromElements(bson.EC.Regex("text", `^a\.b`, "")),
+				),
+			),
+			nil,
+		},
+		{
+			"contains",
+			ListPayload{Contains: toStringPointer("(x)*")},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.ArrayFromElements("$and",
+					bson.VC.DocumentFromElements(bson.EC.Regex("text", `\(x\)\*`, "")),
+				),
+			),
+			nil,
+		},
+		{
+			"prefix and contains",
+			ListPayload{Prefix: toStringPointer("^a"), Contains: toStringPointer("$")},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.ArrayFromElements("$and",
+					bson.VC.DocumentFromElements(bson.EC.Regex("text", `^\^a`, "")),
+					bson.VC.DocumentFromElements(bson.EC.Regex("text", `\$`, "")),
+				),
+			),
+			nil,
+		},
+		{
+			"minLength and maxLength",
+			ListPayload{MinLength: toIntPointer(3), MaxLength: toIntPointer(7)},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.SubDocumentFromElements("length",
+					bson.EC.Int64("$gte", 3),
+					bson.EC.Int64("$lte", 7),
+				),
+			),
+			nil,
+		},
+		{
+			"filters and cursor",
+			ListPayload{
+				CreatedAfter: toTimePointer(createdAt),
+				Prefix:       toStringPointer("race"),
+				MinLength:    toIntPointer(3),
+				Cursor:       newCursor(Message{ID: "123", CreatedAt: createdAt.Add(time.Hour)}, SortCreatedAt, false).String(),
+			},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.SubDocumentFromElements("createdAt", bson.EC.DateTime("$gt", ms(createdAt))),
+				bson.EC.ArrayFromElements("$and",
+					bson.VC.DocumentFromElements(bson.EC.Regex("text", "^race", "")),
+				),
+				bson.EC.SubDocumentFromElements("length", bson.EC.Int64("$gte", 3)),
+				bson.EC.ArrayFromElements("$or",
+					bson.VC.DocumentFromElements(
+						bson.EC.SubDocumentFromElements("createdAt", bson.EC.DateTime("$gt", ms(createdAt.Add(time.Hour)))),
+					),
+					bson.VC.DocumentFromElements(
+						bson.EC.DateTime("createdAt", ms(createdAt.Add(time.Hour))),
+						bson.EC.SubDocumentFromElements("_id", bson.EC.String("$gt", "123")),
+					),
+				),
+			),
+			nil,
+		},
+		{
+			"descending length cursor",
+			ListPayload{
+				Sort:       SortLength,
+				Descending: true,
+				Cursor:     newCursor(Message{ID: "123", Length: 7}, SortLength, true).String(),
+			},
+			bson.NewDocument(
+				notDeleted,
+				bson.EC.ArrayFromElements("$or",
+					bson.VC.DocumentFromElements(
+						bson.EC.SubDocumentFromElements("length", bson.EC.Int64("$lt", 7)),
+					),
+					bson.VC.DocumentFromElements(
+						bson.EC.Int64("length", 7),
+						bson.EC.SubDocumentFromElements("_id", bson.EC.String("$lt", "123")),
+					),
+				),
+			),
+			nil,
+		},
+		{
+			"ErrInvalidCursor",
+			ListPayload{
+				Sort:   SortLength,
+				Cursor: newCursor(Message{ID: "123"}, SortCreatedAt, false).String(),
+			},
+			nil,
+			ErrInvalidCursor,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filter, err := pageFilter(tc.payload)
+			if tc.err == nil {
+				require.NoError(t, err)
+				require.Equal(t, tc.want.ToExtJSON(true), filter.ToExtJSON(true))
+			} else {
+				require.Equal(t, tc.err, err)
+				require.Nil(t, filter)
+			}
+		})
+	}
+}
@@ -4,36 +4,188 @@ package store
 import (
 	"context"
 	"errors"
+	"sort"
+	"time"
 )
 
 var (
 	// ErrNotFound is returned if a Message is not found.
 	ErrNotFound = errors.New("not found")
+
+	// ErrVersionMismatch is returned if a conditional operation expects a different version of a Message.
+	ErrVersionMismatch = errors.New("version mismatch")
 )
 
-// Store describes a store that allows create, read, list, and delete operations on Messages.
+// Store describes a store that allows create, read, update, list, and delete operations on Messages.
+// Each operation that changes a Message appends a Revision to its history.
+// Deleted Messages are moved to the trash, where they can be restored until they are purged.
 type Store interface {
 	Create(ctx context.Context, p MessagePayload) (Message, error)
 	Read(ctx context.Context, id string) (Message, error)
-	List(ctx context.Context, p ListPayload) ([]Message, error)
-	Delete(ctx context.Context, id string) error
+	// ReadAt reads the Message with id as it was at a point in time. ErrNotFound is returned if it did not exist or was deleted at that time.
+	ReadAt(ctx context.Context, id string, at time.Time) (Message, error)
+	// Update replaces the Message with id by p, keeping its CreatedAt, setting its UpdatedAt, and incrementing its Version. If version is not nil, the Message is only updated if it has that Version, and ErrVersionMismatch is returned otherwise.
+	Update(ctx context.Context, id string, p MessagePayload, version *int) (Message, error)
+	// List lists the Messages that match p, one Page at a time.
+	List(ctx context.Context, p ListPayload) (Page, error)
+	// Delete moves the Message with id to the trash and sets its DeletedAt. ErrNotFound is returned if it does not exist or is already in the trash.
+	// If version is not nil, the Message is only deleted if it has that Version, and ErrVersionMismatch is returned otherwise.
+	Delete(ctx context.Context, id string, version *int) error
+	// Trash lists the deleted Messages.
+	Trash(ctx context.Context) ([]Message, error)
+	// Restore moves the Message with id out of the trash and increments its Version. ErrNotFound is returned if it is not in the trash.
+	Restore(ctx context.Context, id string) (Message, error)
+	// Revisions returns the history of the Message with id, oldest first. ErrNotFound is returned if it has no history.
+	Revisions(ctx context.Context, id string) ([]Revision, error)
+	// Purge permanently deletes the Message with id, whether it is in the trash or not, and its history. ErrNotFound is returned if there is neither.
+	Purge(ctx context.Context, id string) error
+	// EmptyTrash permanently deletes the Messages that were moved to the trash before a point in time and their history, and returns how many were deleted.
+	EmptyTrash(ctx context.Context, before time.Time) (int, error)
 }
 
-// MessagePayload represents a payload used to create a Message.
+// MessagePayload represents a payload used to create or update a Message.
 type MessagePayload struct {
-	Text       string
-	Palindrome bool
+	Text              string
+	Length            int
+	Palindrome        bool
+	Mode              string
+	Lang              string
+	LongestPalindrome Substring
+	Distance          *int
+	Suggestion        string
+	Rearrangeable     bool
+	Arrangement       string
+	Sites             []Site
+	Bases             []int
 }
 
 // ListPayload represents a payload used to list Messages.
 type ListPayload struct {
-	Palindrome *bool
+	Palindrome           *bool
+	MinLongestPalindrome *int
+	MaxDistance          *int
+	Rearrangeable        *bool
+	Base                 *int
+	// CreatedAfter and CreatedBefore list the Messages created strictly between them.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Prefix and Contains are matched against Text, and are case-sensitive.
+	Prefix    *string
+	Contains  *string
+	MinLength *int
+	MaxLength *int
+	// Sort defaults to SortCreatedAt.
+	Sort       Sort
+	Descending bool
+	// Limit is the maximum number of Messages in a Page. If it is zero, all Messages are listed in a single Page.
+	Limit int
+	// Cursor is the Next cursor of the previous Page, or empty for the first Page.
+	Cursor string
+}
+
+// Page represents a page of Messages.
+type Page struct {
+	Messages []Message
+	// Next is the cursor of the next Page, or empty if this is the last Page.
+	Next string
 }
 
 // Message represents a string that may be a palindrome.
 type Message struct {
-	ID         string `bson:"_id"`
-	Text       string `bson:"text"`
-	Palindrome bool   `bson:"palindrome"`
-	CreatedAt  string `bson:"createdAt"`
+	ID                string    `bson:"_id"`
+	Text              string    `bson:"text"`
+	Length            int       `bson:"length"`
+	Palindrome        bool      `bson:"palindrome"`
+	Mode              string    `bson:"mode"`
+	Lang              string    `bson:"lang,omitempty"`
+	LongestPalindrome Substring `bson:"longestPalindrome"`
+	Distance          *int      `bson:"distance,omitempty"`
+	Suggestion        string    `bson:"suggestion,omitempty"`
+	Rearrangeable     bool      `bson:"rearrangeable"`
+	Arrangement       string    `bson:"arrangement,omitempty"`
+	Sites             []Site    `bson:"sites,omitempty"`
+	Bases             []int     `bson:"bases,omitempty"`
+	// CreatedAt is stored as a BSON datetime, so it is truncated to milliseconds in MongoDB.
+	CreatedAt time.Time `bson:"createdAt"`
+	// UpdatedAt is zero if the Message was never updated. It is stored as a BSON datetime like CreatedAt.
+	UpdatedAt time.Time `bson:"updatedAt,omitempty"`
+	// Version is 1 when a Message is created, and is incremented each time it is updated or restored.
+	Version int `bson:"version"`
+	// DeletedAt is zero unless the Message is in the trash. It is stored as a BSON datetime like CreatedAt.
+	DeletedAt time.Time `bson:"deletedAt,omitempty"`
+}
+
+// Revision represents a Message as it was from RevisedAt until the next Revision.
+type Revision struct {
+	Message Message `bson:"message"`
+	// RevisedAt is the time the Message was created, updated, deleted, or restored. It is stored as a BSON datetime like Message.CreatedAt.
+	RevisedAt time.Time `bson:"revisedAt"`
+	// Deleted is true if the Message was deleted at RevisedAt, and Message is the last version before it was deleted.
+	Deleted bool `bson:"deleted"`
+}
+
+// TimeLayout is the layout the times of Messages and Revisions are formatted with.
+// It is like time.RFC3339Nano, but has a fixed number of fractional digits, so timestamps in UTC sort like the times they represent.
+const TimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+func newMessage(id string, p MessagePayload, createdAt time.Time, version int) Message {
+	return Message{
+		ID:                id,
+		Text:              p.Text,
+		Length:            p.Length,
+		Palindrome:        p.Palindrome,
+		Mode:              p.Mode,
+		Lang:              p.Lang,
+		LongestPalindrome: p.LongestPalindrome,
+		Distance:          p.Distance,
+		Suggestion:        p.Suggestion,
+		Rearrangeable:     p.Rearrangeable,
+		Arrangement:       p.Arrangement,
+		Sites:             p.Sites,
+		Bases:             p.Bases,
+		CreatedAt:         createdAt,
+		Version:           version,
+	}
+}
+
+// Substring represents a palindromic substring of a Message. Offsets are measured in runes.
+type Substring struct {
+	Text   string `bson:"text"`
+	Start  int    `bson:"start"`
+	End    int    `bson:"end"`
+	Length int    `bson:"length"`
+}
+
+// Site represents a reverse complement palindromic site of a Message. Offsets are measured in nucleotides.
+type Site struct {
+	Sequence string `bson:"sequence"`
+	Start    int    `bson:"start"`
+	End      int    `bson:"end"`
+	Length   int    `bson:"length"`
+}
+
+// sortRevisions sorts the revisions of a Message oldest first. The revision of a deletion follows the revision of the last version, and restoring a Message increments its version.
+func sortRevisions(revs []Revision) {
+	sort.Slice(revs, func(i, j int) bool {
+		if revs[i].Message.Version != revs[j].Message.Version {
+			return revs[i].Message.Version < revs[j].Message.Version
+		}
+		return !revs[i].Deleted && revs[j].Deleted
+	})
+}
+
+// revisionAt returns the Message of the last of revs that is not later than at.
+func revisionAt(revs []Revision, at time.Time) (Message, error) {
+	var msg Message
+	found := false
+	for _, rev := range revs {
+		if rev.RevisedAt.After(at) {
+			break
+		}
+		msg, found = rev.Message, !rev.Deleted
+	}
+	if !found {
+		return Message{}, ErrNotFound
+	}
+	return msg, nil
 }
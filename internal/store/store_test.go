@@ -0,0 +1,229 @@
+package store
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/mongodb/mongo-go-driver/mongo"
+	"github.com/nicholaslam/example-service/internal/idgen"
+	"github.com/stretchr/testify/require"
+)
+
+// storeFactory opens an empty Store, and returns a function that closes it.
+type storeFactory func(t *testing.T) (Store, func())
+
+// storeFactories returns the Stores that must behave alike. MongoDB is only tested if MONGO_TEST_URI is set.
+func storeFactories() []struct {
+	name string
+	open storeFactory
+} {
+	return []struct {
+		name string
+		open storeFactory
+	}{
+		{
+			"temp",
+			func(t *testing.T) (Store, func()) {
+				return NewTempStore(idgen.NewULID()), func() {}
+			},
+		},
+		{
+			"file",
+			func(t *testing.T) (Store, func()) {
+				dir := tempDir(t)
+				fs, err := NewFileStore(dir, idgen.NewULID(), SyncNever)
+				require.NoError(t, err)
+				return fs, func() {
+					fs.Close()
+					os.RemoveAll(dir)
+				}
+			},
+		},
+		{
+			"mongo",
+			func(t *testing.T) (Store, func()) {
+				uri := os.Getenv("MONGO_TEST_URI")
+				if uri == "" {
+					t.Skip("MONGO_TEST_URI is not set")
+				}
+				client, err := mongo.NewClient(uri)
+				require.NoError(t, err)
+				require.NoError(t, client.Connect(context.Background()))
+				db := client.Database("palindrome_test")
+				suffix := idgen.NewULID().New()
+				messages := db.Collection("messages_" + suffix)
+				revisions := db.Collection("revisions_" + suffix)
+				return NewMongoStore(messages, revisions, idgen.NewULID()), func() {
+					messages.Drop(context.Background())
+					revisions.Drop(context.Background())
+					client.Disconnect(context.Background())
+				}
+			},
+		},
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	testCases := []struct {
+		name string
+		// op is called with the ID of a Message with Version 1 that is not in the trash.
+		op   func(ctx context.Context, s Store, id string) error
+		want error
+	}{
+		{
+			"read missing",
+			func(ctx context.Context, s Store, id string) error {
+				_, err := s.Read(ctx, "missing")
+				return err
+			},
+			ErrNotFound,
+		},
+		{
+			"update missing",
+			func(ctx context.Context, s Store, id string) error {
+				_, err := s.Update(ctx, "missing", MessagePayload{Text: "abc"}, nil)
+				return err
+			},
+			ErrNotFound,
+		},
+		{
+			"update version mismatch",
+			func(ctx context.Context, s Store, id string) error {
+				_, err := s.Update(ctx, id, MessagePayload{Text: "abc"}, toIntPointer(2))
+				return err
+			},
+			ErrVersionMismatch,
+		},
+		{
+			"delete",
+			func(ctx context.Context, s Store, id string) error {
+				return s.Delete(ctx, id, toIntPointer(1))
+			},
+			nil,
+		},
+		{
+			"delete missing",
+			func(ctx context.Context, s Store, id string) error {
+				return s.Delete(ctx, "missing", nil)
+			},
+			ErrNotFound,
+		},
+		{
+			"delete missing with version",
+			func(ctx context.Context, s Store, id string) error {
+				return s.Delete(ctx, "missing", toIntPointer(1))
+			},
+			ErrNotFound,
+		},
+		{
+			"delete version mismatch",
+			func(ctx context.Context, s Store, id string) error {
+				return s.Delete(ctx, id, toIntPointer(2))
+			},
+			ErrVersionMismatch,
+		},
+		{
+			"delete trashed",
+			func(ctx context.Context, s Store, id string) error {
+				if err := s.Delete(ctx, id, nil); err != nil {
+					return err
+				}
+				return s.Delete(ctx, id, nil)
+			},
+			ErrNotFound,
+		},
+		{
+			"restore not trashed",
+			func(ctx context.Context, s Store, id string) error {
+				_, err := s.Restore(ctx, id)
+				return err
+			},
+			ErrNotFound,
+		},
+		{
+			"revisions missing",
+			func(ctx context.Context, s Store, id string) error {
+				_, err := s.Revisions(ctx, "missing")
+				return err
+			},
+			ErrNotFound,
+		},
+		{
+			"purge missing",
+			func(ctx context.Context, s Store, id string) error {
+				return s.Purge(ctx, "missing")
+			},
+			ErrNotFound,
+		},
+		{
+			"purge trashed",
+			func(ctx context.Context, s Store, id string) error {
+				if err := s.Delete(ctx, id, nil); err != nil {
+					return err
+				}
+				if err := s.Purge(ctx, id); err != nil {
+					return err
+				}
+				_, err := s.Revisions(ctx, id)
+				return err
+			},
+			ErrNotFound,
+		},
+	}
+
+	for _, f := range storeFactories() {
+		t.Run(f.name, func(t *testing.T) {
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					s, closeStore := f.open(t)
+					defer closeStore()
+					ctx := context.Background()
+					created, err := s.Create(ctx, MessagePayload{Text: "racecar", Palindrome: true})
+					require.NoError(t, err)
+					require.Equal(t, tc.want, tc.op(ctx, s, created.ID))
+				})
+			}
+		})
+	}
+}
+
+func TestStoreEmptyTrash(t *testing.T) {
+	for _, f := range storeFactories() {
+		t.Run(f.name, func(t *testing.T) {
+			s, closeStore := f.open(t)
+			defer closeStore()
+			ctx := context.Background()
+			old, err := s.Create(ctx, MessagePayload{Text: "racecar"})
+			require.NoError(t, err)
+			require.NoError(t, s.Delete(ctx, old.ID, nil))
+			// DeletedAt is stored with millisecond precision in MongoDB.
+			time.Sleep(2 * time.Millisecond)
+			before := time.Now()
+			time.Sleep(2 * time.Millisecond)
+			recent, err := s.Create(ctx, MessagePayload{Text: "kayak"})
+			require.NoError(t, err)
+			require.NoError(t, s.Delete(ctx, recent.ID, nil))
+			kept, err := s.Create(ctx, MessagePayload{Text: "level"})
+			require.NoError(t, err)
+
+			n, err := s.EmptyTrash(ctx, before)
+			require.NoError(t, err)
+			require.Equal(t, 1, n)
+			trash, err := s.Trash(ctx)
+			require.NoError(t, err)
+			require.Len(t, trash, 1)
+			require.Equal(t, recent.ID, trash[0].ID)
+			_, err = s.Restore(ctx, old.ID)
+			require.Equal(t, ErrNotFound, err)
+			_, err = s.Read(ctx, kept.ID)
+			require.NoError(t, err)
+			_, err = s.Revisions(ctx, old.ID)
+			require.Equal(t, ErrNotFound, err)
+			revs, err := s.Revisions(ctx, recent.ID)
+			require.NoError(t, err)
+			require.Len(t, revs, 2)
+		})
+	}
+}
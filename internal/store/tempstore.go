@@ -2,71 +2,282 @@ package store
 
 import (
 	"context"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/satori/go.uuid"
+	"github.com/nicholaslam/example-service/internal/idgen"
 )
 
+// shardCount is the number of shards of a tempStore. It is a power of two, so the shard of an ID is selected by masking its hash.
+const shardCount = 32
+
 type tempStore struct {
-	messages map[string]Message
+	shards [shardCount]*shard
+	ids    idgen.Generator
+	// seq is the sequence number of the last created Message, which orders the Messages of all shards by insertion.
+	seq uint64
+}
+
+// shard holds the Messages whose IDs hash to it.
+// mu guards messages, trash, and revisions, so conditional operations on a Message are atomic.
+type shard struct {
+	mu        sync.RWMutex
+	messages  map[string]entry
+	trash     map[string]entry
+	revisions map[string][]Revision
+}
+
+// entry is a Message and its position in the insertion order of a tempStore.
+type entry struct {
+	msg Message
+	seq uint64
+}
+
+// NewTempStore returns a new store that persists Messages in memory, and generates their IDs with ids.
+// It is safe for concurrent use, and Messages with different IDs are mostly stored in different shards, which are locked independently.
+func NewTempStore(ids idgen.Generator) Store {
+	return newTempStore(ids)
 }
 
-// NewTempStore returns a new store that persists Messages in memory.
-func NewTempStore() Store {
-	return &tempStore{
-		messages: map[string]Message{},
+func newTempStore(ids idgen.Generator) *tempStore {
+	ts := &tempStore{ids: ids}
+	for i := range ts.shards {
+		ts.shards[i] = &shard{
+			messages:  map[string]entry{},
+			trash:     map[string]entry{},
+			revisions: map[string][]Revision{},
+		}
 	}
+	return ts
+}
+
+// shard returns the shard of the Message with id.
+func (ts *tempStore) shard(id string) *shard {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return ts.shards[h.Sum32()&(shardCount-1)]
 }
 
 func (ts *tempStore) Create(ctx context.Context, p MessagePayload) (Message, error) {
-	id := uuid.NewV4().String()
-	msg := Message{
-		ID:         id,
-		Text:       p.Text,
-		Palindrome: p.Palindrome,
-		CreatedAt:  time.Now().UTC().Format(time.RFC3339Nano),
-	}
-	ts.messages[id] = msg
+	id := ts.ids.New()
+	msg := newMessage(id, p, time.Now().UTC(), 1)
+	sh := ts.shard(id)
+	sh.mu.Lock()
+	sh.messages[id] = entry{msg, atomic.AddUint64(&ts.seq, 1)}
+	sh.revisions[id] = append(sh.revisions[id], Revision{Message: msg, RevisedAt: msg.CreatedAt})
+	sh.mu.Unlock()
 	return msg, nil
 }
 
 func (ts *tempStore) Read(ctx context.Context, id string) (Message, error) {
-	msg, ok := ts.messages[id]
+	sh := ts.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	e, ok := sh.messages[id]
+	if !ok {
+		return Message{}, ErrNotFound
+	}
+	return e.msg, nil
+}
+
+func (ts *tempStore) ReadAt(ctx context.Context, id string, at time.Time) (Message, error) {
+	sh := ts.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return revisionAt(sh.revisions[id], at)
+}
+
+func (ts *tempStore) Update(ctx context.Context, id string, p MessagePayload, version *int) (Message, error) {
+	sh := ts.shard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	old, ok := sh.messages[id]
 	if !ok {
 		return Message{}, ErrNotFound
 	}
+	if version != nil && old.msg.Version != *version {
+		return Message{}, ErrVersionMismatch
+	}
+	msg := newMessage(id, p, old.msg.CreatedAt, old.msg.Version+1)
+	msg.UpdatedAt = time.Now().UTC()
+	sh.messages[id] = entry{msg, old.seq}
+	sh.revisions[id] = append(sh.revisions[id], Revision{Message: msg, RevisedAt: msg.UpdatedAt})
 	return msg, nil
 }
 
-func (ts *tempStore) List(ctx context.Context, p ListPayload) ([]Message, error) {
-	msgs := toSlice(ts.messages)
-	if p.Palindrome == nil {
-		return msgs, nil
+// List reads the shards one at a time, so a Page is not a snapshot of all shards at once.
+func (ts *tempStore) List(ctx context.Context, p ListPayload) (Page, error) {
+	var entries []entry
+	for _, sh := range ts.shards {
+		sh.mu.RLock()
+		for _, e := range sh.messages {
+			if matches(e.msg, p) {
+				entries = append(entries, e)
+			}
+		}
+		sh.mu.RUnlock()
 	}
-	var retMsgs []Message
-	for _, m := range msgs {
-		if m.Palindrome == *p.Palindrome {
-			retMsgs = append(retMsgs, m)
+	return paginate(entries, p)
+}
+
+func (ts *tempStore) Delete(ctx context.Context, id string, version *int) error {
+	sh := ts.shard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	e, ok := sh.messages[id]
+	if !ok {
+		return ErrNotFound
+	}
+	if version != nil && e.msg.Version != *version {
+		return ErrVersionMismatch
+	}
+	deletedAt := time.Now().UTC()
+	delete(sh.messages, id)
+	sh.revisions[id] = append(sh.revisions[id], Revision{
+		Message:   e.msg,
+		RevisedAt: deletedAt,
+		Deleted:   true,
+	})
+	e.msg.DeletedAt = deletedAt
+	sh.trash[id] = e
+	return nil
+}
+
+func (ts *tempStore) Trash(ctx context.Context) ([]Message, error) {
+	var entries []entry
+	for _, sh := range ts.shards {
+		sh.mu.RLock()
+		for _, e := range sh.trash {
+			entries = append(entries, e)
 		}
+		sh.mu.RUnlock()
+	}
+	return inOrder(entries), nil
+}
+
+func (ts *tempStore) Restore(ctx context.Context, id string) (Message, error) {
+	sh := ts.shard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	e, ok := sh.trash[id]
+	if !ok {
+		return Message{}, ErrNotFound
 	}
-	return retMsgs, nil
+	e.msg.DeletedAt = time.Time{}
+	e.msg.Version++
+	delete(sh.trash, id)
+	sh.messages[id] = e
+	sh.revisions[id] = append(sh.revisions[id], Revision{Message: e.msg, RevisedAt: time.Now().UTC()})
+	return e.msg, nil
 }
 
-func (ts *tempStore) Delete(ctx context.Context, id string) error {
-	_, ok := ts.messages[id]
+func (ts *tempStore) Revisions(ctx context.Context, id string) ([]Revision, error) {
+	sh := ts.shard(id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	revs, ok := sh.revisions[id]
 	if !ok {
+		return []Revision{}, ErrNotFound
+	}
+	return append([]Revision{}, revs...), nil
+}
+
+func (ts *tempStore) Purge(ctx context.Context, id string) error {
+	sh := ts.shard(id)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	_, inMessages := sh.messages[id]
+	_, inTrash := sh.trash[id]
+	_, inRevisions := sh.revisions[id]
+	if !inMessages && !inTrash && !inRevisions {
 		return ErrNotFound
 	}
-	delete(ts.messages, id)
+	delete(sh.messages, id)
+	delete(sh.trash, id)
+	delete(sh.revisions, id)
 	return nil
 }
 
-func toSlice(m map[string]Message) []Message {
-	s := make([]Message, len(m))
-	i := 0
-	for _, v := range m {
-		s[i] = v
-		i++
+func (ts *tempStore) EmptyTrash(ctx context.Context, before time.Time) (int, error) {
+	n := 0
+	for _, sh := range ts.shards {
+		n += sh.emptyTrash(before)
+	}
+	return n, nil
+}
+
+func (sh *shard) emptyTrash(before time.Time) int {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	n := 0
+	for id, e := range sh.trash {
+		if e.msg.DeletedAt.Before(before) {
+			delete(sh.trash, id)
+			delete(sh.revisions, id)
+			n++
+		}
+	}
+	return n
+}
+
+func matches(m Message, p ListPayload) bool {
+	if p.Palindrome != nil && m.Palindrome != *p.Palindrome {
+		return false
+	}
+	if p.MinLongestPalindrome != nil && m.LongestPalindrome.Length < *p.MinLongestPalindrome {
+		return false
+	}
+	if p.MaxDistance != nil && (m.Distance == nil || *m.Distance > *p.MaxDistance) {
+		return false
+	}
+	if p.Rearrangeable != nil && m.Rearrangeable != *p.Rearrangeable {
+		return false
+	}
+	if p.Base != nil && !containsInt(m.Bases, *p.Base) {
+		return false
+	}
+	if p.CreatedAfter != nil && !m.CreatedAt.After(*p.CreatedAfter) {
+		return false
+	}
+	if p.CreatedBefore != nil && !m.CreatedAt.Before(*p.CreatedBefore) {
+		return false
+	}
+	if p.Prefix != nil && !strings.HasPrefix(m.Text, *p.Prefix) {
+		return false
+	}
+	if p.Contains != nil && !strings.Contains(m.Text, *p.Contains) {
+		return false
+	}
+	if p.MinLength != nil && m.Length < *p.MinLength {
+		return false
+	}
+	if p.MaxLength != nil && m.Length > *p.MaxLength {
+		return false
+	}
+	return true
+}
+
+func containsInt(s []int, i int) bool {
+	for _, v := range s {
+		if v == i {
+			return true
+		}
+	}
+	return false
+}
+
+// inOrder returns the Messages of entries in insertion order.
+func inOrder(entries []entry) []Message {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].seq < entries[j].seq
+	})
+	msgs := make([]Message, len(entries))
+	for i, e := range entries {
+		msgs[i] = e.msg
 	}
-	return s
+	return msgs
 }
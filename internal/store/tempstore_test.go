@@ -2,9 +2,12 @@ package store
 
 import (
 	"context"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/nicholaslam/example-service/internal/idgen"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -12,8 +15,20 @@ func toBoolPointer(b bool) *bool {
 	return &b
 }
 
+func toIntPointer(i int) *int {
+	return &i
+}
+
+func toStringPointer(s string) *string {
+	return &s
+}
+
+func toTimePointer(t time.Time) *time.Time {
+	return &t
+}
+
 func TestNewTempStore(t *testing.T) {
-	require.NotNil(t, NewTempStore())
+	require.NotNil(t, NewTempStore(idgen.NewULID()))
 }
 
 func TestTempStoreCreate(t *testing.T) {
@@ -27,23 +42,37 @@ func TestTempStoreCreate(t *testing.T) {
 			MessagePayload{
 				Text:       "racecar",
 				Palindrome: true,
+				LongestPalindrome: Substring{
+					Text:   "racecar",
+					Start:  0,
+					End:    7,
+					Length: 7,
+				},
 			},
 			Message{
 				Text:       "racecar",
 				Palindrome: true,
+				LongestPalindrome: Substring{
+					Text:   "racecar",
+					Start:  0,
+					End:    7,
+					Length: 7,
+				},
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ts := NewTempStore()
+			ts := NewTempStore(idgen.NewULID())
 			msg, err := ts.Create(context.Background(), tc.payload)
 			require.NoError(t, err)
 			require.NotEmpty(t, msg.ID)
 			require.Equal(t, tc.want.Text, msg.Text)
 			require.Equal(t, tc.want.Palindrome, msg.Palindrome)
+			require.Equal(t, tc.want.LongestPalindrome, msg.LongestPalindrome)
 			require.NotEmpty(t, msg.CreatedAt)
+			require.Equal(t, 1, msg.Version)
 		})
 	}
 }
@@ -68,7 +97,7 @@ func TestTempStoreRead(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ts := NewTempStore()
+			ts := NewTempStore(idgen.NewULID())
 			cMsg, _ := ts.Create(context.Background(), tc.payload)
 			if tc.errMsg == "" {
 				rMsg, err := ts.Read(context.Background(), cMsg.ID)
@@ -154,22 +183,264 @@ func TestTempStoreList(t *testing.T) {
 			ListPayload{Palindrome: toBoolPointer(false)},
 			2,
 		},
+		{
+			"minLongestPalindrome=3",
+			[]MessagePayload{
+				{
+					Text:              "racecar",
+					Palindrome:        true,
+					LongestPalindrome: Substring{Length: 7},
+				},
+				{
+					Text:              "a toyota",
+					Palindrome:        false,
+					LongestPalindrome: Substring{Length: 3},
+				},
+				{
+					Text:              "abc",
+					Palindrome:        false,
+					LongestPalindrome: Substring{Length: 1},
+				},
+			},
+			ListPayload{MinLongestPalindrome: toIntPointer(3)},
+			2,
+		},
+		{
+			"palindrome=false and minLongestPalindrome=3",
+			[]MessagePayload{
+				{
+					Text:              "racecar",
+					Palindrome:        true,
+					LongestPalindrome: Substring{Length: 7},
+				},
+				{
+					Text:              "a toyota",
+					Palindrome:        false,
+					LongestPalindrome: Substring{Length: 3},
+				},
+				{
+					Text:              "abc",
+					Palindrome:        false,
+					LongestPalindrome: Substring{Length: 1},
+				},
+			},
+			ListPayload{Palindrome: toBoolPointer(false), MinLongestPalindrome: toIntPointer(3)},
+			1,
+		},
+		{
+			"maxDistance=1",
+			[]MessagePayload{
+				{
+					Text:       "racecar",
+					Palindrome: true,
+					Distance:   toIntPointer(0),
+				},
+				{
+					Text:       "racecr",
+					Palindrome: false,
+					Distance:   toIntPointer(1),
+				},
+				{
+					Text:       "abc",
+					Palindrome: false,
+					Distance:   toIntPointer(2),
+				},
+				{
+					Text:       "too long",
+					Palindrome: false,
+				},
+			},
+			ListPayload{MaxDistance: toIntPointer(1)},
+			2,
+		},
+		{
+			"rearrangeable=true",
+			[]MessagePayload{
+				{
+					Text:          "racecar",
+					Palindrome:    true,
+					Rearrangeable: true,
+					Arrangement:   "acrerca",
+				},
+				{
+					Text:          "aabb",
+					Palindrome:    false,
+					Rearrangeable: true,
+					Arrangement:   "abba",
+				},
+				{
+					Text:          "abc",
+					Palindrome:    false,
+					Rearrangeable: false,
+				},
+			},
+			ListPayload{Rearrangeable: toBoolPointer(true)},
+			2,
+		},
+		{
+			"base=2",
+			[]MessagePayload{
+				{
+					Text:  "585",
+					Mode:  "numeric",
+					Bases: []int{2, 8, 10},
+				},
+				{
+					Text:  "121",
+					Mode:  "numeric",
+					Bases: []int{3, 7, 8, 10},
+				},
+				{
+					Text: "racecar",
+				},
+			},
+			ListPayload{Base: toIntPointer(2)},
+			1,
+		},
+		{
+			"createdAfter",
+			[]MessagePayload{
+				{Text: "racecar", Length: 7},
+				{Text: "race", Length: 4},
+				{Text: "a racecar", Length: 9},
+			},
+			ListPayload{CreatedAfter: toTimePointer(time.Now().Add(-time.Hour))},
+			3,
+		},
+		{
+			"createdBefore",
+			[]MessagePayload{
+				{Text: "racecar", Length: 7},
+				{Text: "race", Length: 4},
+				{Text: "a racecar", Length: 9},
+			},
+			ListPayload{CreatedBefore: toTimePointer(time.Now().Add(-time.Hour))},
+			0,
+		},
+		{
+			"prefix=race",
+			[]MessagePayload{
+				{Text: "racecar", Length: 7},
+				{Text: "race", Length: 4},
+				{Text: "a racecar", Length: 9},
+			},
+			ListPayload{Prefix: toStringPointer("race")},
+			2,
+		},
+		{
+			"contains=car",
+			[]MessagePayload{
+				{Text: "racecar", Length: 7},
+				{Text: "race", Length: 4},
+				{Text: "a racecar", Length: 9},
+			},
+			ListPayload{Contains: toStringPointer("car")},
+			2,
+		},
+		{
+			"prefix=race&contains=car",
+			[]MessagePayload{
+				{Text: "racecar", Length: 7},
+				{Text: "race", Length: 4},
+				{Text: "a racecar", Length: 9},
+			},
+			ListPayload{Prefix: toStringPointer("race"), Contains: toStringPointer("car")},
+			1,
+		},
+		{
+			"minLength=5&maxLength=8",
+			[]MessagePayload{
+				{Text: "racecar", Length: 7},
+				{Text: "race", Length: 4},
+				{Text: "a racecar", Length: 9},
+			},
+			ListPayload{MinLength: toIntPointer(5), MaxLength: toIntPointer(8)},
+			1,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ts := NewTempStore()
+			ts := NewTempStore(idgen.NewULID())
 			for _, p := range tc.messagePayloads {
 				ts.Create(context.Background(), p)
 			}
-			msgs, err := ts.List(context.Background(), tc.listPayload)
+			page, err := ts.List(context.Background(), tc.listPayload)
 			require.NoError(t, err)
-			require.Equal(t, tc.length, len(msgs))
+			require.Equal(t, tc.length, len(page.Messages))
 		})
 	}
 }
 
-func TestTempStoreDelete(t *testing.T) {
+func TestTempStoreListPages(t *testing.T) {
+	testCases := []struct {
+		name        string
+		listPayload ListPayload
+		want        [][]string
+	}{
+		{
+			"sort=text",
+			ListPayload{Sort: SortText, Limit: 2},
+			[][]string{{"ab", "kayak"}, {"racecar", "xyz"}},
+		},
+		{
+			"sort=-text",
+			ListPayload{Sort: SortText, Descending: true, Limit: 3},
+			[][]string{{"xyz", "racecar", "kayak"}, {"ab"}},
+		},
+		{
+			"sort=length",
+			ListPayload{Sort: SortLength, Limit: 1},
+			[][]string{{"ab"}, {"xyz"}, {"kayak"}, {"racecar"}},
+		},
+		{
+			"no limit",
+			ListPayload{Sort: SortText},
+			[][]string{{"ab", "kayak", "racecar", "xyz"}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := NewTempStore(idgen.NewULID())
+			for _, text := range []string{"racecar", "xyz", "kayak", "ab"} {
+				ts.Create(context.Background(), MessagePayload{Text: text, Length: len(text)})
+			}
+			var got [][]string
+			p := tc.listPayload
+			for {
+				page, err := ts.List(context.Background(), p)
+				require.NoError(t, err)
+				var texts []string
+				for _, msg := range page.Messages {
+					texts = append(texts, msg.Text)
+				}
+				got = append(got, texts)
+				if page.Next == "" {
+					break
+				}
+				p.Cursor = page.Next
+			}
+			require.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestTempStoreListInvalidCursor(t *testing.T) {
+	ts := NewTempStore(idgen.NewULID())
+	_, err := ts.List(context.Background(), ListPayload{Cursor: "invalid"})
+	require.Equal(t, ErrInvalidCursor, err)
+
+	ts.Create(context.Background(), MessagePayload{Text: "abc"})
+	ts.Create(context.Background(), MessagePayload{Text: "xyz"})
+	page, err := ts.List(context.Background(), ListPayload{Sort: SortText, Limit: 1})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.Next)
+	_, err = ts.List(context.Background(), ListPayload{Sort: SortLength, Limit: 1, Cursor: page.Next})
+	require.Equal(t, ErrInvalidCursor, err)
+}
+
+func TestTempStoreUpdate(t *testing.T) {
 	testCases := []struct {
 		name    string
 		payload MessagePayload
@@ -177,7 +448,16 @@ func TestTempStoreDelete(t *testing.T) {
 	}{
 		{
 			"success",
-			MessagePayload{},
+			MessagePayload{
+				Text:       "abc",
+				Palindrome: false,
+				LongestPalindrome: Substring{
+					Text:   "a",
+					Start:  0,
+					End:    1,
+					Length: 1,
+				},
+			},
 			"",
 		},
 		{
@@ -189,63 +469,493 @@ func TestTempStoreDelete(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			ts := NewTempStore()
+			ts := NewTempStore(idgen.NewULID())
+			created, _ := ts.Create(context.Background(), MessagePayload{Text: "racecar", Palindrome: true})
+			if tc.errMsg == "" {
+				msg, err := ts.Update(context.Background(), created.ID, tc.payload, nil)
+				require.NoError(t, err)
+				require.Equal(t, created.ID, msg.ID)
+				require.Equal(t, tc.payload.Text, msg.Text)
+				require.Equal(t, tc.payload.Palindrome, msg.Palindrome)
+				require.Equal(t, tc.payload.LongestPalindrome, msg.LongestPalindrome)
+				require.Equal(t, created.CreatedAt, msg.CreatedAt)
+				require.False(t, msg.UpdatedAt.IsZero())
+				require.Equal(t, created.Version+1, msg.Version)
+				read, err := ts.Read(context.Background(), created.ID)
+				require.NoError(t, err)
+				require.Equal(t, msg, read)
+			} else {
+				msg, err := ts.Update(context.Background(), "uuid", tc.payload, nil)
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestTempStoreUpdateVersion(t *testing.T) {
+	testCases := []struct {
+		name    string
+		version *int
+		errMsg  string
+	}{
+		{
+			"version",
+			toIntPointer(1),
+			"",
+		},
+		{
+			"ErrVersionMismatch",
+			toIntPointer(2),
+			"version mismatch",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := NewTempStore(idgen.NewULID())
+			created, _ := ts.Create(context.Background(), MessagePayload{Text: "racecar", Palindrome: true})
+			msg, err := ts.Update(context.Background(), created.ID, MessagePayload{Text: "abc"}, tc.version)
+			read, _ := ts.Read(context.Background(), created.ID)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, 2, msg.Version)
+				require.Equal(t, msg, read)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+				require.Equal(t, created, read)
+			}
+		})
+	}
+}
+
+func TestTempStoreDelete(t *testing.T) {
+	testCases := []struct {
+		name    string
+		payload MessagePayload
+		version *int
+		errMsg  string
+	}{
+		{
+			"success",
+			MessagePayload{},
+			nil,
+			"",
+		},
+		{
+			"version",
+			MessagePayload{},
+			toIntPointer(1),
+			"",
+		},
+		{
+			"ErrVersionMismatch",
+			MessagePayload{},
+			toIntPointer(2),
+			"version mismatch",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ts := NewTempStore(idgen.NewULID())
 			msg, _ := ts.Create(context.Background(), tc.payload)
+			err := ts.Delete(context.Background(), msg.ID, tc.version)
 			if tc.errMsg == "" {
-				err := ts.Delete(context.Background(), msg.ID)
 				require.NoError(t, err)
+				_, err = ts.Read(context.Background(), msg.ID)
+				require.Equal(t, ErrNotFound, err)
 			} else {
-				err := ts.Delete(context.Background(), "uuid")
 				require.Error(t, err)
 				require.Equal(t, tc.errMsg, err.Error())
+				_, err = ts.Read(context.Background(), msg.ID)
+				require.NoError(t, err)
 			}
 		})
 	}
 }
 
-func TestToSlice(t *testing.T) {
-	now := time.Now().UTC().Format(time.RFC3339Nano)
+func TestTempStoreDeleteNotFound(t *testing.T) {
+	ts := NewTempStore(idgen.NewULID())
+	err := ts.Delete(context.Background(), "uuid", nil)
+	require.Error(t, err)
+	require.Equal(t, "not found", err.Error())
+}
+
+func TestTempStoreRevisions(t *testing.T) {
+	ts := NewTempStore(idgen.NewULID())
+	created, err := ts.Create(context.Background(), MessagePayload{Text: "racecar", Palindrome: true})
+	require.NoError(t, err)
+	updated, err := ts.Update(context.Background(), created.ID, MessagePayload{Text: "abc"}, nil)
+	require.NoError(t, err)
+	require.NoError(t, ts.Delete(context.Background(), created.ID, nil))
+
+	revs, err := ts.Revisions(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	require.Equal(t, Revision{created, created.CreatedAt, false}, revs[0])
+	require.Equal(t, Revision{updated, updated.UpdatedAt, false}, revs[1])
+	require.Equal(t, updated, revs[2].Message)
+	require.True(t, revs[2].Deleted)
+
+	msg, err := ts.ReadAt(context.Background(), created.ID, time.Now())
+	require.Equal(t, ErrNotFound, err)
+	require.Empty(t, msg)
+	msg, err = ts.ReadAt(context.Background(), created.ID, updated.UpdatedAt)
+	require.NoError(t, err)
+	require.Equal(t, updated, msg)
+
+	require.NoError(t, ts.Purge(context.Background(), created.ID))
+	revs, err = ts.Revisions(context.Background(), created.ID)
+	require.Equal(t, ErrNotFound, err)
+	require.Empty(t, revs)
+	require.Equal(t, ErrNotFound, ts.Purge(context.Background(), created.ID))
+}
+
+func TestTempStoreTrash(t *testing.T) {
+	ts := NewTempStore(idgen.NewULID())
+	created, err := ts.Create(context.Background(), MessagePayload{Text: "racecar", Palindrome: true})
+	require.NoError(t, err)
+	require.NoError(t, ts.Delete(context.Background(), created.ID, nil))
+
+	_, err = ts.Read(context.Background(), created.ID)
+	require.Equal(t, ErrNotFound, err)
+	page, err := ts.List(context.Background(), ListPayload{})
+	require.NoError(t, err)
+	require.Empty(t, page.Messages)
+	_, err = ts.Update(context.Background(), created.ID, MessagePayload{Text: "abc"}, nil)
+	require.Equal(t, ErrNotFound, err)
+	require.Equal(t, ErrNotFound, ts.Delete(context.Background(), created.ID, nil))
+
+	trash, err := ts.Trash(context.Background())
+	require.NoError(t, err)
+	require.Len(t, trash, 1)
+	require.Equal(t, created.ID, trash[0].ID)
+	require.NotEmpty(t, trash[0].DeletedAt)
+
+	restored, err := ts.Restore(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Empty(t, restored.DeletedAt)
+	require.Equal(t, created.Version+1, restored.Version)
+	msg, err := ts.Read(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, restored, msg)
+	trash, err = ts.Trash(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, trash)
+	_, err = ts.Restore(context.Background(), created.ID)
+	require.Equal(t, ErrNotFound, err)
+
+	revs, err := ts.Revisions(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	require.True(t, revs[1].Deleted)
+	require.Equal(t, restored, revs[2].Message)
+	require.False(t, revs[2].Deleted)
+}
+
+func TestTempStoreEmptyTrash(t *testing.T) {
+	ts := NewTempStore(idgen.NewULID())
+	before := time.Now()
+	for i := 0; i < 2; i++ {
+		msg, err := ts.Create(context.Background(), MessagePayload{Text: "racecar"})
+		require.NoError(t, err)
+		require.NoError(t, ts.Delete(context.Background(), msg.ID, nil))
+	}
+
+	n, err := ts.EmptyTrash(context.Background(), before)
+	require.NoError(t, err)
+	require.Equal(t, 0, n)
+	n, err = ts.EmptyTrash(context.Background(), time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, 2, n)
+	trash, err := ts.Trash(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, trash)
+	revisions := 0
+	for _, sh := range ts.(*tempStore).shards {
+		revisions += len(sh.revisions)
+	}
+	require.Equal(t, 0, revisions)
+}
+
+func TestSortRevisions(t *testing.T) {
+	revs := []Revision{
+		{Message{Version: 2}, time.Time{}, true},
+		{Message{Version: 2}, time.Time{}, false},
+		{Message{Version: 1}, time.Time{}, false},
+	}
+	sortRevisions(revs)
+	require.Equal(t, []Revision{
+		{Message{Version: 1}, time.Time{}, false},
+		{Message{Version: 2}, time.Time{}, false},
+		{Message{Version: 2}, time.Time{}, true},
+	}, revs)
+}
+
+func TestRevisionAt(t *testing.T) {
+	revs := []Revision{
+		{Message{Text: "racecar", Version: 1}, time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), false},
+		{Message{Text: "abc", Version: 2}, time.Date(2018, 1, 2, 0, 0, 0, 500000000, time.UTC), false},
+		{Message{Text: "abc", Version: 2}, time.Date(2018, 1, 3, 0, 0, 0, 0, time.UTC), true},
+	}
 
 	testCases := []struct {
-		name string
-		m    map[string]Message
+		name   string
+		at     string
+		want   Message
+		errMsg string
 	}{
 		{
-			"empty map",
-			map[string]Message{},
+			"before create",
+			"2017-12-31T23:59:59Z",
+			Message{},
+			"not found",
 		},
 		{
-			"non-empty map",
-			map[string]Message{
-				"123": {
-					ID:         "123",
-					Text:       "racecar",
-					Palindrome: true,
-					CreatedAt:  now,
-				},
-				"456": {
-					ID:         "456",
-					Text:       "a toyota",
-					Palindrome: false,
-					CreatedAt:  now,
-				},
-				"789": {
-					ID:         "789",
-					Text:       "abc",
-					Palindrome: false,
-					CreatedAt:  now,
-				},
-			},
+			"created",
+			"2018-01-01T00:00:00Z",
+			Message{Text: "racecar", Version: 1},
+			"",
+		},
+		{
+			"before update",
+			"2018-01-02T00:00:00.25Z",
+			Message{Text: "racecar", Version: 1},
+			"",
+		},
+		{
+			"updated",
+			"2018-01-02T12:00:00+02:00",
+			Message{Text: "abc", Version: 2},
+			"",
+		},
+		{
+			"deleted",
+			"2018-01-03T00:00:00Z",
+			Message{},
+			"not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			at, _ := time.Parse(time.RFC3339Nano, tc.at)
+			msg, err := revisionAt(revs, at)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, msg)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, msg)
+			}
+		})
+	}
+}
+
+func TestInOrder(t *testing.T) {
+	now := time.Now().UTC()
+	racecar := Message{ID: "123", Text: "racecar", Palindrome: true, CreatedAt: now}
+	toyota := Message{ID: "456", Text: "a toyota", CreatedAt: now}
+	abc := Message{ID: "789", Text: "abc", CreatedAt: now}
+
+	testCases := []struct {
+		name    string
+		entries []entry
+		want    []Message
+	}{
+		{
+			"no entries",
+			[]entry{},
+			[]Message{},
+		},
+		{
+			"entries",
+			[]entry{{toyota, 7}, {abc, 9}, {racecar, 2}},
+			[]Message{racecar, toyota, abc},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, inOrder(tc.entries))
+		})
+	}
+}
+
+func TestTempStoreListInsertionOrder(t *testing.T) {
+	testCases := []struct {
+		name        string
+		listPayload ListPayload
+		reversed    bool
+	}{
+		{
+			"sort=createdAt",
+			ListPayload{Limit: 7},
+			false,
+		},
+		{
+			"sort=-createdAt",
+			ListPayload{Descending: true, Limit: 7},
+			true,
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			msgs := toSlice(tc.m)
-			require.Equal(t, len(tc.m), len(msgs))
-			for _, v := range tc.m {
-				require.Contains(t, msgs, v)
+			ts := NewTempStore(idgen.NewUUID()).(*tempStore)
+			createdAt := time.Now().UTC()
+			var want []string
+			for i := 0; i < 3*shardCount; i++ {
+				msg, err := ts.Create(context.Background(), MessagePayload{Text: "racecar"})
+				require.NoError(t, err)
+				// Random UUIDs only keep the insertion order if it breaks the ties between equal creation times.
+				sh := ts.shard(msg.ID)
+				e := sh.messages[msg.ID]
+				e.msg.CreatedAt = createdAt
+				sh.messages[msg.ID] = e
+				want = append(want, msg.ID)
+			}
+			if tc.reversed {
+				for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+					want[i], want[j] = want[j], want[i]
+				}
 			}
+
+			var got []string
+			p := tc.listPayload
+			for {
+				page, err := ts.List(context.Background(), p)
+				require.NoError(t, err)
+				for _, msg := range page.Messages {
+					got = append(got, msg.ID)
+				}
+				if page.Next == "" {
+					break
+				}
+				p.Cursor = page.Next
+			}
+			require.Equal(t, want, got)
 		})
 	}
 }
+
+func TestTempStoreTrashInsertionOrder(t *testing.T) {
+	ts := NewTempStore(idgen.NewUUID())
+	var want []string
+	for i := 0; i < 3*shardCount; i++ {
+		msg, err := ts.Create(context.Background(), MessagePayload{Text: "racecar"})
+		require.NoError(t, err)
+		want = append(want, msg.ID)
+	}
+
+	for _, id := range want {
+		require.NoError(t, ts.Delete(context.Background(), id, nil))
+	}
+	trash, err := ts.Trash(context.Background())
+	require.NoError(t, err)
+	var got []string
+	for _, msg := range trash {
+		got = append(got, msg.ID)
+	}
+	require.Equal(t, want, got)
+}
+
+// TestTempStoreConcurrent runs every operation of a tempStore from many goroutines, so the race detector finds unsynchronized accesses.
+func TestTempStoreConcurrent(t *testing.T) {
+	const (
+		workers    = 16
+		iterations = 50
+	)
+	ts := NewTempStore(idgen.NewULID())
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				msg, err := ts.Create(ctx, MessagePayload{Text: "racecar", Palindrome: true})
+				if !assert.NoError(t, err) {
+					return
+				}
+				_, err = ts.Read(ctx, msg.ID)
+				assert.NoError(t, err)
+				_, err = ts.Update(ctx, msg.ID, MessagePayload{Text: "kayak", Palindrome: true}, nil)
+				assert.NoError(t, err)
+				_, err = ts.List(ctx, ListPayload{Palindrome: toBoolPointer(true), Limit: 10})
+				assert.NoError(t, err)
+				_, err = ts.Revisions(ctx, msg.ID)
+				assert.NoError(t, err)
+				if i%2 == 0 {
+					continue
+				}
+				assert.NoError(t, ts.Delete(ctx, msg.ID, nil))
+				_, err = ts.Trash(ctx)
+				assert.NoError(t, err)
+				if i%3 == 0 {
+					_, err = ts.Restore(ctx, msg.ID)
+					assert.NoError(t, err)
+				}
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < iterations; i++ {
+			_, err := ts.EmptyTrash(ctx, time.Now().Add(-time.Hour))
+			assert.NoError(t, err)
+		}
+	}()
+	wg.Wait()
+
+	page, err := ts.List(ctx, ListPayload{})
+	require.NoError(t, err)
+	trash, err := ts.Trash(ctx)
+	require.NoError(t, err)
+	// Odd iterations delete their Message, and those that are also multiples of 3 restore it.
+	kept := 0
+	for i := 0; i < iterations; i++ {
+		if i%2 == 0 || i%3 == 0 {
+			kept++
+		}
+	}
+	require.Len(t, page.Messages, workers*kept)
+	require.Len(t, trash, workers*(iterations-kept))
+	for _, msg := range page.Messages {
+		require.Equal(t, "kayak", msg.Text)
+	}
+}
+
+// TestTempStoreConcurrentUpdates updates the same Message from many goroutines, so every update must be applied exactly once.
+func TestTempStoreConcurrentUpdates(t *testing.T) {
+	const workers = 32
+	ts := NewTempStore(idgen.NewULID())
+	ctx := context.Background()
+	created, err := ts.Create(ctx, MessagePayload{Text: "racecar"})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ts.Update(ctx, created.ID, MessagePayload{Text: "kayak"}, nil)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	msg, err := ts.Read(ctx, created.ID)
+	require.NoError(t, err)
+	require.Equal(t, workers+1, msg.Version)
+	revs, err := ts.Revisions(ctx, created.ID)
+	require.NoError(t, err)
+	require.Len(t, revs, workers+1)
+}
@@ -5,18 +5,24 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"mime"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
+	"time"
 
 	kitendpoint "github.com/go-kit/kit/endpoint"
 	kithttp "github.com/go-kit/kit/transport/http"
 	"github.com/gorilla/mux"
 	"github.com/nicholaslam/example-service/internal/endpoint"
+	"github.com/nicholaslam/example-service/internal/idgen"
 )
 
 var (
-	errBadRouting = errors.New("inconsistent mapping between route and handler")
-	errBadRequest = errors.New("bad request")
+	errBadRouting           = errors.New("inconsistent mapping between route and handler")
+	errBadRequest           = errors.New("bad request")
+	errUnsupportedMediaType = errors.New("unsupported media type")
 )
 
 // MakeCreateHTTPHandler mounts the create endpoint.
@@ -24,16 +30,46 @@ func MakeCreateHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
 	return kithttp.NewServer(
 		endpoint,
 		decodeCreateRequest,
+		encodeMessageResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakeCheckHTTPHandler mounts the check endpoint.
+func MakeCheckHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		decodeCheckRequest,
 		encodeResponse,
 		kithttp.ServerErrorEncoder(encodeError),
 	)
 }
 
 // MakeReadHTTPHandler mounts the read endpoint.
-func MakeReadHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
+func MakeReadHTTPHandler(endpoint kitendpoint.Endpoint, ids idgen.Generator) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		validateID(ids, decodeReadRequest),
+		encodeMessageResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakeRevisionsHTTPHandler mounts the revisions endpoint.
+func MakeRevisionsHTTPHandler(endpoint kitendpoint.Endpoint, ids idgen.Generator) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		validateID(ids, decodeRevisionsRequest),
+		encodeResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakeUpdateHTTPHandler mounts the update endpoint. PUT replaces a Message, and PATCH only changes the fields that are set.
+func MakeUpdateHTTPHandler(endpoint kitendpoint.Endpoint, ids idgen.Generator) http.Handler {
 	return kithttp.NewServer(
 		endpoint,
-		decodeReadRequest,
+		validateID(ids, decodeUpdateRequest),
 		encodeResponse,
 		kithttp.ServerErrorEncoder(encodeError),
 	)
@@ -50,10 +86,50 @@ func MakeListHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
 }
 
 // MakeDeleteHTTPHandler mounts the delete endpoint.
-func MakeDeleteHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
+func MakeDeleteHTTPHandler(endpoint kitendpoint.Endpoint, ids idgen.Generator) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		validateID(ids, decodeDeleteRequest),
+		encodeResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakeTrashHTTPHandler mounts the trash endpoint.
+func MakeTrashHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		decodeTrashRequest,
+		encodeResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakeRestoreHTTPHandler mounts the restore endpoint.
+func MakeRestoreHTTPHandler(endpoint kitendpoint.Endpoint, ids idgen.Generator) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		validateID(ids, decodeRestoreRequest),
+		encodeResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakePalindromesHTTPHandler mounts the palindromes endpoint.
+func MakePalindromesHTTPHandler(endpoint kitendpoint.Endpoint, ids idgen.Generator) http.Handler {
+	return kithttp.NewServer(
+		endpoint,
+		validateID(ids, decodePalindromesRequest),
+		encodeResponse,
+		kithttp.ServerErrorEncoder(encodeError),
+	)
+}
+
+// MakeUploadHTTPHandler mounts the upload endpoint.
+func MakeUploadHTTPHandler(endpoint kitendpoint.Endpoint) http.Handler {
 	return kithttp.NewServer(
 		endpoint,
-		decodeDeleteRequest,
+		decodeUploadRequest,
 		encodeResponse,
 		kithttp.ServerErrorEncoder(encodeError),
 	)
@@ -67,27 +143,141 @@ func decodeCreateRequest(ctx context.Context, r *http.Request) (interface{}, err
 	return req, nil
 }
 
+// decodeCheckRequest accepts either a single create request or an array of them.
+func decodeCheckRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var raw json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&raw); err != nil {
+		return nil, errBadRequest
+	}
+	var req endpoint.CheckRequest
+	if len(raw) > 0 && raw[0] == '[' {
+		if err := json.Unmarshal(raw, &req.Payloads); err != nil {
+			return nil, errBadRequest
+		}
+		return req, nil
+	}
+	var p endpoint.CreateRequest
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, errBadRequest
+	}
+	req.Payloads = []endpoint.CreateRequest{p}
+	req.Single = true
+	return req, nil
+}
+
+// validateID rejects the requests whose ID could not have been generated by ids before they are decoded by dec.
+func validateID(ids idgen.Generator, dec kithttp.DecodeRequestFunc) kithttp.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		id, ok := mux.Vars(r)["id"]
+		if !ok {
+			return nil, errBadRouting
+		}
+		if !ids.Valid(id) {
+			return nil, errBadRequest
+		}
+		return dec(ctx, r)
+	}
+}
+
 func decodeReadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
 	vars := mux.Vars(r)
 	id, ok := vars["id"]
 	if !ok {
 		return nil, errBadRouting
 	}
-	return endpoint.ReadRequest{ID: id}, nil
+	at, err := queryTime(r.URL.Query(), "at")
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.ReadRequest{
+		ID:          id,
+		IfNoneMatch: parseCondition(r.Header.Get("If-None-Match"), true),
+		At:          at,
+	}, nil
+}
+
+func decodeRevisionsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errBadRouting
+	}
+	return endpoint.RevisionsRequest{ID: id}, nil
+}
+
+func decodeUpdateRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errBadRouting
+	}
+	var req endpoint.UpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errBadRequest
+	}
+	req.ID = id
+	req.Partial = r.Method == http.MethodPatch
+	return req, nil
 }
 
 func decodeListRequest(ctx context.Context, r *http.Request) (interface{}, error) {
-	palindromeRaw := strings.ToLower(r.URL.Query().Get("palindrome"))
-	if palindromeRaw == "" {
-		return endpoint.ListRequest{Palindrome: nil}, nil
-	} else if palindromeRaw == "true" {
-		palindrome := true
-		return endpoint.ListRequest{Palindrome: &palindrome}, nil
-	} else if palindromeRaw == "false" {
-		palindrome := false
-		return endpoint.ListRequest{Palindrome: &palindrome}, nil
+	q := r.URL.Query()
+	palindrome, err := queryBool(q, "palindrome")
+	if err != nil {
+		return nil, err
 	}
-	return nil, errBadRequest
+	minLongestPalindrome, err := queryInt(q, "minLongestPalindrome")
+	if err != nil {
+		return nil, err
+	}
+	maxDistance, err := queryInt(q, "maxDistance")
+	if err != nil {
+		return nil, err
+	}
+	rearrangeable, err := queryBool(q, "rearrangeable")
+	if err != nil {
+		return nil, err
+	}
+	base, err := queryInt(q, "base")
+	if err != nil {
+		return nil, err
+	}
+	createdAfter, err := queryTime(q, "createdAfter")
+	if err != nil {
+		return nil, err
+	}
+	createdBefore, err := queryTime(q, "createdBefore")
+	if err != nil {
+		return nil, err
+	}
+	minLength, err := queryInt(q, "minLength")
+	if err != nil {
+		return nil, err
+	}
+	maxLength, err := queryInt(q, "maxLength")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := queryInt(q, "limit")
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.ListRequest{
+		Palindrome:           palindrome,
+		MinLongestPalindrome: minLongestPalindrome,
+		MaxDistance:          maxDistance,
+		Rearrangeable:        rearrangeable,
+		Base:                 base,
+		CreatedAfter:         createdAfter,
+		CreatedBefore:        createdBefore,
+		Prefix:               queryString(q, "prefix"),
+		Contains:             queryString(q, "contains"),
+		MinLength:            minLength,
+		MaxLength:            maxLength,
+		Sort:                 queryString(q, "sort"),
+		Limit:                limit,
+		Cursor:               queryString(q, "cursor"),
+	}, nil
 }
 
 func decodeDeleteRequest(ctx context.Context, r *http.Request) (interface{}, error) {
@@ -96,7 +286,155 @@ func decodeDeleteRequest(ctx context.Context, r *http.Request) (interface{}, err
 	if !ok {
 		return nil, errBadRouting
 	}
-	return endpoint.DeleteRequest{ID: id}, nil
+	purge, err := queryBool(r.URL.Query(), "purge")
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.DeleteRequest{
+		ID:      id,
+		IfMatch: parseCondition(r.Header.Get("If-Match"), false),
+		Purge:   purge != nil && *purge,
+	}, nil
+}
+
+func decodeTrashRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	return endpoint.TrashRequest{}, nil
+}
+
+func decodeRestoreRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errBadRouting
+	}
+	return endpoint.RestoreRequest{ID: id}, nil
+}
+
+func decodePalindromesRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	vars := mux.Vars(r)
+	id, ok := vars["id"]
+	if !ok {
+		return nil, errBadRouting
+	}
+	q := r.URL.Query()
+	minLength, err := queryInt(q, "minLength")
+	if err != nil {
+		return nil, err
+	}
+	offset, err := queryInt(q, "offset")
+	if err != nil {
+		return nil, err
+	}
+	limit, err := queryInt(q, "limit")
+	if err != nil {
+		return nil, err
+	}
+	return endpoint.PalindromesRequest{
+		ID:        id,
+		MinLength: minLength,
+		Offset:    offset,
+		Limit:     limit,
+	}, nil
+}
+
+// decodeUploadRequest passes the body through without reading it, so it can be streamed.
+func decodeUploadRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "text/plain" {
+		return nil, errUnsupportedMediaType
+	}
+	req := endpoint.UploadRequest{Body: r.Body}
+	if mode := r.URL.Query().Get("mode"); mode != "" {
+		req.Mode = &mode
+	}
+	return req, nil
+}
+
+// etag returns the entity tag of a Message with version.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseCondition parses the entity tags of an If-Match or If-None-Match header, and returns nil if it is empty.
+// Weak entity tags only match if weak is true, and entity tags that are not the version of a Message never match.
+func parseCondition(header string, weak bool) *endpoint.Condition {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return nil
+	}
+	if header == "*" {
+		return &endpoint.Condition{Any: true}
+	}
+	c := &endpoint.Condition{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = tag[len("W/"):]
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		v, err := strconv.Atoi(tag[1 : len(tag)-1])
+		if err != nil {
+			continue
+		}
+		c.Versions = append(c.Versions, v)
+	}
+	return c
+}
+
+// queryBool returns nil if the query parameter named key is empty.
+func queryBool(q url.Values, key string) (*bool, error) {
+	raw := strings.ToLower(q.Get(key))
+	switch raw {
+	case "":
+		return nil, nil
+	case "true":
+		b := true
+		return &b, nil
+	case "false":
+		b := false
+		return &b, nil
+	}
+	return nil, errBadRequest
+}
+
+// queryString returns nil if the query parameter named key is empty.
+func queryString(q url.Values, key string) *string {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil
+	}
+	return &raw
+}
+
+// queryInt returns nil if the query parameter named key is empty. Negative integers are invalid.
+func queryInt(q url.Values, key string) (*int, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	i, err := strconv.Atoi(raw)
+	if err != nil || i < 0 {
+		return nil, errBadRequest
+	}
+	return &i, nil
+}
+
+// queryTime returns nil if the query parameter named key is empty. Times are formatted as RFC 3339.
+func queryTime(q url.Values, key string) (*time.Time, error) {
+	raw := q.Get(key)
+	if raw == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return nil, errBadRequest
+	}
+	return &t, nil
 }
 
 func encodeResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
@@ -108,19 +446,37 @@ func encodeResponse(ctx context.Context, w http.ResponseWriter, response interfa
 	return json.NewEncoder(w).Encode(response)
 }
 
+// encodeMessageResponse encodes a created or read Message with its version as ETag. A Message read at a point in time has no ETag.
+func encodeMessageResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	if msg, ok := response.(endpoint.MessageResponse); ok && msg.Version > 0 {
+		w.Header().Set("ETag", etag(msg.Version))
+	}
+	return encodeResponse(ctx, w, response)
+}
+
 func encodeError(ctx context.Context, err error, w http.ResponseWriter) {
 	if err == nil {
 		panic("cannot encode nil error")
 	}
+	if e, ok := err.(endpoint.NotModifiedError); ok {
+		w.Header().Set("ETag", etag(e.Version))
+	}
 	w.WriteHeader(statusCode(err))
 }
 
 func statusCode(err error) int {
+	if _, ok := err.(endpoint.NotModifiedError); ok {
+		return http.StatusNotModified
+	}
 	switch err {
 	case endpoint.ErrNotFound:
 		return http.StatusNotFound
 	case endpoint.ErrBadRequest, errBadRequest:
 		return http.StatusBadRequest
+	case endpoint.ErrPreconditionFailed:
+		return http.StatusPreconditionFailed
+	case errUnsupportedMediaType:
+		return http.StatusUnsupportedMediaType
 	default:
 		return http.StatusInternalServerError
 	}
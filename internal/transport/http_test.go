@@ -5,13 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/nicholaslam/example-service/internal/endpoint"
+	"github.com/nicholaslam/example-service/internal/idgen"
 	"github.com/nicholaslam/example-service/internal/service"
 	"github.com/stretchr/testify/require"
 )
@@ -26,18 +30,92 @@ func (ms *mockService) Create(ctx context.Context, p service.MessagePayload) (se
 	return ms.msg, ms.err
 }
 
+func (ms *mockService) Check(ctx context.Context, ps []service.MessagePayload) ([]service.Message, error) {
+	if ms.err != nil {
+		return []service.Message{}, ms.err
+	}
+	msgs := []service.Message{}
+	for range ps {
+		msgs = append(msgs, ms.msg)
+	}
+	return msgs, nil
+}
+
 func (ms *mockService) Read(ctx context.Context, id string) (service.Message, error) {
 	return ms.msg, ms.err
 }
 
-func (ms *mockService) List(ctx context.Context, p service.ListPayload) ([]service.Message, error) {
-	return ms.msgs, ms.err
+func (ms *mockService) ReadAt(ctx context.Context, id string, at time.Time) (service.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockService) Revisions(ctx context.Context, id string) ([]service.Revision, error) {
+	if ms.err != nil {
+		return []service.Revision{}, ms.err
+	}
+	revs := []service.Revision{}
+	for _, msg := range ms.msgs {
+		revs = append(revs, service.Revision{Message: msg, RevisedAt: msg.UpdatedAt})
+	}
+	return revs, nil
+}
+
+func (ms *mockService) Update(ctx context.Context, id string, p service.UpdatePayload) (service.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockService) List(ctx context.Context, p service.ListPayload) (service.MessagesPage, error) {
+	return service.MessagesPage{Messages: ms.msgs}, ms.err
 }
 
-func (ms *mockService) Delete(ctx context.Context, id string) error {
+func (ms *mockService) Delete(ctx context.Context, id string, p service.DeletePayload) error {
 	return ms.err
 }
 
+func (ms *mockService) Trash(ctx context.Context) ([]service.Message, error) {
+	return ms.msgs, ms.err
+}
+
+func (ms *mockService) Restore(ctx context.Context, id string) (service.Message, error) {
+	return ms.msg, ms.err
+}
+
+func (ms *mockService) EmptyTrash(ctx context.Context) (int, error) {
+	return len(ms.msgs), ms.err
+}
+
+func (ms *mockService) CheckStream(ctx context.Context, r io.Reader, mode service.Mode) (service.StreamResult, error) {
+	if ms.err != nil {
+		return service.StreamResult{}, ms.err
+	}
+	b, err := ioutil.ReadAll(r)
+	return service.StreamResult{Palindrome: ms.msg.Palindrome, Mode: mode, Size: int64(len(b))}, err
+}
+
+func (ms *mockService) Palindromes(ctx context.Context, id string, p service.PalindromesPayload) (service.PalindromesPage, error) {
+	if ms.err != nil {
+		return service.PalindromesPage{}, ms.err
+	}
+	sub := service.PalindromicSubstring{
+		Text:   ms.msg.LongestPalindrome.Text,
+		Length: ms.msg.LongestPalindrome.Length,
+		Count:  1,
+		Start:  ms.msg.LongestPalindrome.Start,
+	}
+	return service.PalindromesPage{Palindromes: []service.PalindromicSubstring{sub}, Total: 1}, nil
+}
+
+// anyID accepts every ID, so requests can use short IDs.
+type anyID struct{}
+
+func (anyID) New() string {
+	return "123"
+}
+
+func (anyID) Valid(id string) bool {
+	return true
+}
+
 func toStringPointer(s string) *string {
 	return &s
 }
@@ -46,6 +124,14 @@ func toBoolPointer(b bool) *bool {
 	return &b
 }
 
+func toIntPointer(i int) *int {
+	return &i
+}
+
+func toTimePointer(t time.Time) *time.Time {
+	return &t
+}
+
 func TestMakeCreateHTTPHandler(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 
@@ -95,19 +181,203 @@ func TestMakeCreateHTTPHandler(t *testing.T) {
 	}
 }
 
+func TestMakeCheckHTTPHandler(t *testing.T) {
+	svc := &mockService{
+		service.Message{
+			Text:       "racecar",
+			Palindrome: true,
+			Mode:       service.ModeStrict,
+		},
+		nil,
+		nil,
+	}
+	want := endpoint.MessageResponse{
+		Text:       "racecar",
+		Palindrome: true,
+		Mode:       "strict",
+	}
+
+	testCases := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{
+			"single",
+			`{"text": "racecar"}`,
+			http.StatusOK,
+			toJSON(want),
+		},
+		{
+			"array",
+			` [{"text": "racecar"}, {"text": "racecar"}]`,
+			http.StatusOK,
+			toJSON([]endpoint.MessageResponse{want, want}),
+		},
+		{
+			"empty array",
+			`[]`,
+			http.StatusOK,
+			"[]\n",
+		},
+		{
+			"missing text",
+			`[{"text": "racecar"}, {}]`,
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"invalid json",
+			`[{"text": "racecar"`,
+			http.StatusBadRequest,
+			"",
+		},
+		{
+			"invalid payload",
+			`"racecar"`,
+			http.StatusBadRequest,
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/v1/check", strings.NewReader(tc.body))
+			MakeCheckHTTPHandler(endpoint.MakeCheckEndpoint(svc)).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			require.Equal(t, tc.want, w.Body.String())
+		})
+	}
+}
+
+func toJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b) + "\n"
+}
+
 func TestMakeReadHTTPHandler(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 
+	testCases := []struct {
+		name        string
+		payload     endpoint.CreateRequest
+		at          string
+		ifNoneMatch string
+		svc         service.Service
+		status      int
+		etag        string
+		want        endpoint.MessageResponse
+	}{
+		{
+			"success",
+			endpoint.CreateRequest{
+				Text: toStringPointer("racecar"),
+			},
+			"",
+			"",
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					CreatedAt:  now,
+				},
+				nil,
+				nil,
+			},
+			http.StatusOK,
+			"",
+			endpoint.MessageResponse{
+				ID:         "123",
+				Text:       "racecar",
+				Palindrome: true,
+				CreatedAt:  now,
+			},
+		},
+		{
+			"if none match",
+			endpoint.CreateRequest{},
+			"",
+			`W/"2"`,
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			http.StatusNotModified,
+			`"2"`,
+			endpoint.MessageResponse{},
+		},
+		{
+			"at",
+			endpoint.CreateRequest{},
+			now,
+			`W/"2"`,
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			http.StatusOK,
+			"",
+			endpoint.MessageResponse{
+				ID:        "123",
+				Text:      "racecar",
+				CreatedAt: now,
+				Version:   2,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			b, _ := json.Marshal(tc.payload)
+			r, _ := http.NewRequest("GET", "/api/v1/messages/123", bytes.NewReader(b))
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			if tc.at != "" {
+				r.URL.RawQuery = "at=" + tc.at
+			}
+			if tc.ifNoneMatch != "" {
+				r.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+			MakeReadHTTPHandler(endpoint.MakeReadEndpoint(tc.svc), anyID{}).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			require.Equal(t, tc.etag, w.Header().Get("ETag"))
+			var res endpoint.MessageResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			require.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestMakeUpdateHTTPHandler(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
 	testCases := []struct {
 		name    string
-		payload endpoint.CreateRequest
+		method  string
+		payload endpoint.UpdateRequest
 		svc     service.Service
 		status  int
 		want    endpoint.MessageResponse
 	}{
 		{
-			"success",
-			endpoint.CreateRequest{
+			"PUT",
+			"PUT",
+			endpoint.UpdateRequest{
 				Text: toStringPointer("racecar"),
 			},
 			&mockService{
@@ -116,6 +386,44 @@ func TestMakeReadHTTPHandler(t *testing.T) {
 					Text:       "racecar",
 					Palindrome: true,
 					CreatedAt:  now,
+					UpdatedAt:  now,
+				},
+				nil,
+				nil,
+			},
+			http.StatusOK,
+			endpoint.MessageResponse{
+				ID:         "123",
+				Text:       "racecar",
+				Palindrome: true,
+				CreatedAt:  now,
+				UpdatedAt:  now,
+			},
+		},
+		{
+			"PUT without text",
+			"PUT",
+			endpoint.UpdateRequest{
+				Mode: toStringPointer("lenient"),
+			},
+			&mockService{},
+			http.StatusBadRequest,
+			endpoint.MessageResponse{},
+		},
+		{
+			"PATCH",
+			"PATCH",
+			endpoint.UpdateRequest{
+				Mode: toStringPointer("lenient"),
+			},
+			&mockService{
+				service.Message{
+					ID:         "123",
+					Text:       "racecar",
+					Palindrome: true,
+					Mode:       service.ModeLenient,
+					CreatedAt:  now,
+					UpdatedAt:  now,
 				},
 				nil,
 				nil,
@@ -125,18 +433,34 @@ func TestMakeReadHTTPHandler(t *testing.T) {
 				ID:         "123",
 				Text:       "racecar",
 				Palindrome: true,
+				Mode:       "lenient",
 				CreatedAt:  now,
+				UpdatedAt:  now,
 			},
 		},
+		{
+			"PATCH not found",
+			"PATCH",
+			endpoint.UpdateRequest{
+				Text: toStringPointer("racecar"),
+			},
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			http.StatusNotFound,
+			endpoint.MessageResponse{},
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			w := httptest.NewRecorder()
 			b, _ := json.Marshal(tc.payload)
-			r, _ := http.NewRequest("GET", "/api/v1/messages/123", bytes.NewReader(b))
+			r, _ := http.NewRequest(tc.method, "/api/v1/messages/123", bytes.NewReader(b))
 			r = mux.SetURLVars(r, map[string]string{"id": "123"})
-			MakeReadHTTPHandler(endpoint.MakeReadEndpoint(tc.svc)).ServeHTTP(w, r)
+			MakeUpdateHTTPHandler(endpoint.MakeUpdateEndpoint(tc.svc), anyID{}).ServeHTTP(w, r)
 			require.Equal(t, tc.status, w.Code)
 			var res endpoint.MessageResponse
 			json.Unmarshal(w.Body.Bytes(), &res)
@@ -198,9 +522,9 @@ func TestMakeListHTTPHandler(t *testing.T) {
 			r, _ := http.NewRequest("GET", "/api/v1/messages", nil)
 			MakeListHTTPHandler(endpoint.MakeListEndpoint(tc.svc)).ServeHTTP(w, r)
 			require.Equal(t, tc.status, w.Code)
-			var res []endpoint.MessageResponse
+			var res endpoint.ListResponse
 			json.Unmarshal(w.Body.Bytes(), &res)
-			require.Equal(t, tc.want, res)
+			require.Equal(t, tc.want, res.Messages)
 		})
 	}
 }
@@ -211,6 +535,7 @@ func TestMakeDeleteHTTPHandler(t *testing.T) {
 	testCases := []struct {
 		name    string
 		payload endpoint.CreateRequest
+		ifMatch string
 		svc     service.Service
 		status  int
 	}{
@@ -219,6 +544,7 @@ func TestMakeDeleteHTTPHandler(t *testing.T) {
 			endpoint.CreateRequest{
 				Text: toStringPointer("racecar"),
 			},
+			"",
 			&mockService{
 				service.Message{
 					ID:         "123",
@@ -231,6 +557,38 @@ func TestMakeDeleteHTTPHandler(t *testing.T) {
 			},
 			http.StatusNoContent,
 		},
+		{
+			"if match",
+			endpoint.CreateRequest{},
+			`"2"`,
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			http.StatusNoContent,
+		},
+		{
+			"if match mismatch",
+			endpoint.CreateRequest{},
+			`"1"`,
+			&mockService{
+				service.Message{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   2,
+				},
+				nil,
+				nil,
+			},
+			http.StatusPreconditionFailed,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -239,66 +597,597 @@ func TestMakeDeleteHTTPHandler(t *testing.T) {
 			b, _ := json.Marshal(tc.payload)
 			r, _ := http.NewRequest("DELETE", "/api/v1/messages/123", bytes.NewReader(b))
 			r = mux.SetURLVars(r, map[string]string{"id": "123"})
-			MakeDeleteHTTPHandler(endpoint.MakeDeleteEndpoint(tc.svc)).ServeHTTP(w, r)
+			if tc.ifMatch != "" {
+				r.Header.Set("If-Match", tc.ifMatch)
+			}
+			MakeDeleteHTTPHandler(endpoint.MakeDeleteEndpoint(tc.svc), anyID{}).ServeHTTP(w, r)
 			require.Equal(t, tc.status, w.Code)
 		})
 	}
 }
 
-func TestDecodeListRequest(t *testing.T) {
+func TestMakeUploadHTTPHandler(t *testing.T) {
 	testCases := []struct {
-		name   string
-		query  string
-		want   endpoint.ListRequest
-		errMsg string
+		name        string
+		contentType string
+		query       string
+		body        string
+		svc         service.Service
+		status      int
+		want        endpoint.UploadResponse
 	}{
 		{
-			"no palindrome query",
-			"",
-			endpoint.ListRequest{Palindrome: nil},
-			"",
-		},
-		{
-			"palindrome=true",
-			"true",
-			endpoint.ListRequest{Palindrome: toBoolPointer(true)},
-			"",
+			"success",
+			"text/plain; charset=utf-8",
+			"mode=lenient",
+			"a toyota",
+			&mockService{
+				service.Message{Palindrome: true},
+				nil,
+				nil,
+			},
+			http.StatusOK,
+			endpoint.UploadResponse{
+				Palindrome: true,
+				Mode:       "lenient",
+				Size:       8,
+			},
 		},
 		{
-			"palindrome=false",
-			"false",
-			endpoint.ListRequest{Palindrome: toBoolPointer(false)},
+			"unsupported media type",
+			"application/json",
 			"",
+			`{"text": "a toyota"}`,
+			&mockService{},
+			http.StatusUnsupportedMediaType,
+			endpoint.UploadResponse{},
 		},
 		{
-			"invalid palindrome query",
-			"invalid",
-			endpoint.ListRequest{},
-			errBadRequest.Error(),
+			"invalid mode",
+			"text/plain",
+			"mode=word",
+			"a toyota",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrInvalidMode,
+			},
+			http.StatusBadRequest,
+			endpoint.UploadResponse{},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			r, _ := http.NewRequest("GET", "/api/v1/messages", nil)
-			q := r.URL.Query()
-			q.Add("palindrome", tc.query)
-			r.URL.RawQuery = q.Encode()
-			req, err := decodeListRequest(context.Background(), r)
-			if tc.errMsg == "" {
-				require.NoError(t, err)
-				require.Equal(t, tc.want, req)
-			} else {
-				require.Error(t, err)
-				require.Equal(t, tc.errMsg, err.Error())
-				require.Nil(t, req)
-			}
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/v1/uploads?"+tc.query, strings.NewReader(tc.body))
+			r.Header.Set("Content-Type", tc.contentType)
+			MakeUploadHTTPHandler(endpoint.MakeUploadEndpoint(tc.svc)).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			var res endpoint.UploadResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			require.Equal(t, tc.want, res)
 		})
 	}
 }
 
-func TestDecodeCreateRequestError(t *testing.T) {
-	reader := bytes.NewReader([]byte("hello, world!"))
+func TestMakePalindromesHTTPHandler(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		svc    service.Service
+		status int
+		want   endpoint.PalindromesResponse
+	}{
+		{
+			"success",
+			"minLength=3&offset=0&limit=10",
+			&mockService{
+				service.Message{
+					LongestPalindrome: service.Substring{
+						Text:   "racecar",
+						Start:  0,
+						End:    7,
+						Length: 7,
+					},
+				},
+				nil,
+				nil,
+			},
+			http.StatusOK,
+			endpoint.PalindromesResponse{
+				Palindromes: []endpoint.PalindromicSubstringResponse{
+					{
+						Text:   "racecar",
+						Length: 7,
+						Count:  1,
+						Start:  0,
+					},
+				},
+				Total: 1,
+			},
+		},
+		{
+			"invalid limit",
+			"limit=-1",
+			&mockService{},
+			http.StatusBadRequest,
+			endpoint.PalindromesResponse{},
+		},
+		{
+			"not found",
+			"",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			http.StatusNotFound,
+			endpoint.PalindromesResponse{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/api/v1/messages/123/palindromes?"+tc.query, nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			MakePalindromesHTTPHandler(endpoint.MakePalindromesEndpoint(tc.svc), anyID{}).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			var res endpoint.PalindromesResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			require.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestMakeRevisionsHTTPHandler(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		status int
+		want   []endpoint.RevisionResponse
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{},
+				[]service.Message{
+					{ID: "123", Text: "racecar", CreatedAt: now, UpdatedAt: now, Version: 2},
+				},
+				nil,
+			},
+			http.StatusOK,
+			[]endpoint.RevisionResponse{
+				{
+					Message:   endpoint.MessageResponse{ID: "123", Text: "racecar", CreatedAt: now, UpdatedAt: now, Version: 2},
+					RevisedAt: now,
+				},
+			},
+		},
+		{
+			"not found",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			http.StatusNotFound,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/api/v1/messages/123/revisions", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			MakeRevisionsHTTPHandler(endpoint.MakeRevisionsEndpoint(tc.svc), anyID{}).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			var res []endpoint.RevisionResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			require.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestMakeTrashHTTPHandler(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		status int
+		want   []endpoint.MessageResponse
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{},
+				[]service.Message{
+					{ID: "123", Text: "racecar", CreatedAt: now, Version: 1, DeletedAt: now},
+				},
+				nil,
+			},
+			http.StatusOK,
+			[]endpoint.MessageResponse{
+				{ID: "123", Text: "racecar", CreatedAt: now, Version: 1, DeletedAt: now},
+			},
+		},
+		{
+			"unhandled error",
+			&mockService{
+				service.Message{},
+				nil,
+				errors.New("error"),
+			},
+			http.StatusInternalServerError,
+			nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("GET", "/api/v1/trash", nil)
+			MakeTrashHTTPHandler(endpoint.MakeTrashEndpoint(tc.svc)).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			var res []endpoint.MessageResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			require.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestMakeRestoreHTTPHandler(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name   string
+		svc    service.Service
+		status int
+		want   endpoint.MessageResponse
+	}{
+		{
+			"success",
+			&mockService{
+				service.Message{ID: "123", Text: "racecar", CreatedAt: now, Version: 2},
+				nil,
+				nil,
+			},
+			http.StatusOK,
+			endpoint.MessageResponse{ID: "123", Text: "racecar", CreatedAt: now, Version: 2},
+		},
+		{
+			"not found",
+			&mockService{
+				service.Message{},
+				nil,
+				service.ErrNotFound,
+			},
+			http.StatusNotFound,
+			endpoint.MessageResponse{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r, _ := http.NewRequest("POST", "/api/v1/messages/123/restore", nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			MakeRestoreHTTPHandler(endpoint.MakeRestoreEndpoint(tc.svc), anyID{}).ServeHTTP(w, r)
+			require.Equal(t, tc.status, w.Code)
+			var res endpoint.MessageResponse
+			json.Unmarshal(w.Body.Bytes(), &res)
+			require.Equal(t, tc.want, res)
+		})
+	}
+}
+
+func TestDecodeReadRequest(t *testing.T) {
+	at := time.Date(2018, 1, 2, 3, 4, 5, 600000000, time.UTC)
+
+	testCases := []struct {
+		name   string
+		query  string
+		want   interface{}
+		errMsg string
+	}{
+		{
+			"success",
+			"",
+			endpoint.ReadRequest{ID: "123"},
+			"",
+		},
+		{
+			"at",
+			"at=2018-01-02T03:04:05.6Z",
+			endpoint.ReadRequest{ID: "123", At: &at},
+			"",
+		},
+		{
+			"invalid at",
+			"at=yesterday",
+			nil,
+			errBadRequest.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/api/v1/messages/123?"+tc.query, nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			req, err := decodeReadRequest(context.Background(), r)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, req)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, req)
+			}
+		})
+	}
+}
+
+func TestDecodeDeleteRequest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		want   interface{}
+		errMsg string
+	}{
+		{
+			"success",
+			"",
+			endpoint.DeleteRequest{ID: "123"},
+			"",
+		},
+		{
+			"purge",
+			"purge=true",
+			endpoint.DeleteRequest{ID: "123", Purge: true},
+			"",
+		},
+		{
+			"invalid purge",
+			"purge=maybe",
+			nil,
+			errBadRequest.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest("DELETE", "/api/v1/messages/123?"+tc.query, nil)
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			req, err := decodeDeleteRequest(context.Background(), r)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, req)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, req)
+			}
+		})
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	testCases := []struct {
+		name   string
+		ids    idgen.Generator
+		vars   map[string]string
+		errMsg string
+	}{
+		{
+			"valid id",
+			idgen.NewULID(),
+			map[string]string{"id": "01BX5ZZKBKACTAV9WEVGEMMVRZ"},
+			"",
+		},
+		{
+			"invalid id",
+			idgen.NewULID(),
+			map[string]string{"id": "123"},
+			errBadRequest.Error(),
+		},
+		{
+			"id of another scheme",
+			idgen.NewULID(),
+			map[string]string{"id": "6ba7b810-9dad-41d1-80b4-00c04fd430c8"},
+			errBadRequest.Error(),
+		},
+		{
+			"id of an accepted scheme",
+			idgen.Accept(idgen.NewULID(), idgen.NewUUID()),
+			map[string]string{"id": "6ba7b810-9dad-41d1-80b4-00c04fd430c8"},
+			"",
+		},
+		{
+			"id of a scheme that is not accepted",
+			idgen.Accept(idgen.NewULID(), idgen.NewUUID()),
+			map[string]string{"id": "5b1a2b3c4d5e6f7a8b9c0d1e"},
+			errBadRequest.Error(),
+		},
+		{
+			"no id",
+			idgen.NewULID(),
+			map[string]string{},
+			errBadRouting.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/api/v1/messages/"+tc.vars["id"], nil)
+			r = mux.SetURLVars(r, tc.vars)
+			req, err := validateID(tc.ids, decodeReadRequest)(context.Background(), r)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, endpoint.ReadRequest{ID: tc.vars["id"]}, req)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, req)
+			}
+		})
+	}
+}
+
+func TestDecodeListRequest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		query  string
+		want   endpoint.ListRequest
+		errMsg string
+	}{
+		{
+			"no palindrome query",
+			"palindrome=",
+			endpoint.ListRequest{Palindrome: nil},
+			"",
+		},
+		{
+			"palindrome=true",
+			"palindrome=true",
+			endpoint.ListRequest{Palindrome: toBoolPointer(true)},
+			"",
+		},
+		{
+			"palindrome=false",
+			"palindrome=false",
+			endpoint.ListRequest{Palindrome: toBoolPointer(false)},
+			"",
+		},
+		{
+			"invalid palindrome query",
+			"palindrome=invalid",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"minLongestPalindrome=3",
+			"palindrome=true&minLongestPalindrome=3",
+			endpoint.ListRequest{Palindrome: toBoolPointer(true), MinLongestPalindrome: toIntPointer(3)},
+			"",
+		},
+		{
+			"maxDistance=1",
+			"maxDistance=1",
+			endpoint.ListRequest{MaxDistance: toIntPointer(1)},
+			"",
+		},
+		{
+			"rearrangeable=true",
+			"rearrangeable=true",
+			endpoint.ListRequest{Rearrangeable: toBoolPointer(true)},
+			"",
+		},
+		{
+			"base=2",
+			"base=2",
+			endpoint.ListRequest{Base: toIntPointer(2)},
+			"",
+		},
+		{
+			"sort=-length&limit=10&cursor=abc",
+			"sort=-length&limit=10&cursor=abc",
+			endpoint.ListRequest{Sort: toStringPointer("-length"), Limit: toIntPointer(10), Cursor: toStringPointer("abc")},
+			"",
+		},
+		{
+			"createdAfter&createdBefore",
+			"createdAfter=2018-06-01T00:00:00Z&createdBefore=2018-07-01T00:00:00Z",
+			endpoint.ListRequest{
+				CreatedAfter:  toTimePointer(time.Date(2018, 6, 1, 0, 0, 0, 0, time.UTC)),
+				CreatedBefore: toTimePointer(time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC)),
+			},
+			"",
+		},
+		{
+			"invalid createdAfter query",
+			"createdAfter=yesterday",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"prefix&contains",
+			"prefix=race&contains=car",
+			endpoint.ListRequest{Prefix: toStringPointer("race"), Contains: toStringPointer("car")},
+			"",
+		},
+		{
+			"minLength&maxLength",
+			"minLength=3&maxLength=7",
+			endpoint.ListRequest{MinLength: toIntPointer(3), MaxLength: toIntPointer(7)},
+			"",
+		},
+		{
+			"invalid maxLength query",
+			"maxLength=invalid",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"negative limit query",
+			"limit=-1",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"invalid base query",
+			"base=invalid",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"invalid rearrangeable query",
+			"rearrangeable=invalid",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"invalid maxDistance query",
+			"maxDistance=invalid",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"negative minLongestPalindrome query",
+			"minLongestPalindrome=-1",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+		{
+			"invalid minLongestPalindrome query",
+			"minLongestPalindrome=invalid",
+			endpoint.ListRequest{},
+			errBadRequest.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest("GET", "/api/v1/messages?"+tc.query, nil)
+			req, err := decodeListRequest(context.Background(), r)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, req)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, req)
+			}
+		})
+	}
+}
+
+func TestDecodeCreateRequestError(t *testing.T) {
+	reader := bytes.NewReader([]byte("hello, world!"))
 	r, _ := http.NewRequest("POST", "/api/v1/messages", reader)
 	req, err := decodeCreateRequest(context.Background(), r)
 	require.Error(t, err)
@@ -314,6 +1203,78 @@ func TestDecodeReadRequestError(t *testing.T) {
 	require.Empty(t, req)
 }
 
+func TestDecodeUpdateRequest(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		body   string
+		want   interface{}
+		errMsg string
+	}{
+		{
+			"PUT",
+			"PUT",
+			`{"text":"racecar"}`,
+			endpoint.UpdateRequest{ID: "123", Text: toStringPointer("racecar")},
+			"",
+		},
+		{
+			"PATCH",
+			"PATCH",
+			`{"mode":"lenient"}`,
+			endpoint.UpdateRequest{ID: "123", Mode: toStringPointer("lenient"), Partial: true},
+			"",
+		},
+		{
+			"invalid JSON",
+			"PATCH",
+			"hello, world!",
+			nil,
+			errBadRequest.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, _ := http.NewRequest(tc.method, "/api/v1/messages/123", strings.NewReader(tc.body))
+			r = mux.SetURLVars(r, map[string]string{"id": "123"})
+			req, err := decodeUpdateRequest(context.Background(), r)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, req)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, req)
+			}
+		})
+	}
+}
+
+func TestDecodeUpdateRequestError(t *testing.T) {
+	r, _ := http.NewRequest("PUT", "/api/v1/messages/123", nil)
+	req, err := decodeUpdateRequest(context.Background(), r)
+	require.Error(t, err)
+	require.Equal(t, errBadRouting.Error(), err.Error())
+	require.Empty(t, req)
+}
+
+func TestDecodeRevisionsRequestError(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/api/v1/messages/123/revisions", nil)
+	req, err := decodeRevisionsRequest(context.Background(), r)
+	require.Error(t, err)
+	require.Equal(t, errBadRouting.Error(), err.Error())
+	require.Empty(t, req)
+}
+
+func TestDecodeRestoreRequestError(t *testing.T) {
+	r, _ := http.NewRequest("POST", "/api/v1/messages/123/restore", nil)
+	req, err := decodeRestoreRequest(context.Background(), r)
+	require.Error(t, err)
+	require.Equal(t, errBadRouting.Error(), err.Error())
+	require.Empty(t, req)
+}
+
 func TestDecodeDeleteRequestError(t *testing.T) {
 	r, _ := http.NewRequest("DELETE", "/api/v1/messages/123", nil)
 	req, err := decodeDeleteRequest(context.Background(), r)
@@ -322,13 +1283,21 @@ func TestDecodeDeleteRequestError(t *testing.T) {
 	require.Empty(t, req)
 }
 
+func TestDecodePalindromesRequestError(t *testing.T) {
+	r, _ := http.NewRequest("GET", "/api/v1/messages/123/palindromes", nil)
+	req, err := decodePalindromesRequest(context.Background(), r)
+	require.Error(t, err)
+	require.Equal(t, errBadRouting.Error(), err.Error())
+	require.Empty(t, req)
+}
+
 func TestEncodeResponse(t *testing.T) {
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 
 	testCases := []struct {
 		name string
 		res  interface{}
-		err  error
+		etag string
 	}{
 		{
 			"success",
@@ -338,12 +1307,23 @@ func TestEncodeResponse(t *testing.T) {
 				Palindrome: true,
 				CreatedAt:  now,
 			},
-			nil,
+			"",
+		},
+		{
+			"version",
+			endpoint.MessageResponse{
+				ID:         "123",
+				Text:       "racecar",
+				Palindrome: true,
+				CreatedAt:  now,
+				Version:    3,
+			},
+			"",
 		},
 		{
 			"nil response",
 			nil,
-			nil,
+			"",
 		},
 	}
 
@@ -355,36 +1335,151 @@ func TestEncodeResponse(t *testing.T) {
 				contentType := w.Header()["Content-Type"]
 				require.Equal(t, []string{"application/json; charset=utf-8"}, contentType)
 			}
+			require.Equal(t, tc.etag, w.Header().Get("ETag"))
 			require.NoError(t, err)
 		})
 	}
 }
 
+func TestEncodeMessageResponse(t *testing.T) {
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	testCases := []struct {
+		name string
+		res  interface{}
+		etag string
+	}{
+		{
+			"no version",
+			endpoint.MessageResponse{
+				ID:        "123",
+				Text:      "racecar",
+				CreatedAt: now,
+			},
+			"",
+		},
+		{
+			"version",
+			endpoint.MessageResponse{
+				ID:        "123",
+				Text:      "racecar",
+				CreatedAt: now,
+				Version:   3,
+			},
+			`"3"`,
+		},
+		{
+			"at",
+			endpoint.MessageAtResponse{
+				MessageResponse: endpoint.MessageResponse{
+					ID:        "123",
+					Text:      "racecar",
+					CreatedAt: now,
+					Version:   3,
+				},
+			},
+			"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			require.NoError(t, encodeMessageResponse(context.Background(), w, tc.res))
+			require.Equal(t, tc.etag, w.Header().Get("ETag"))
+			require.Equal(t, []string{"application/json; charset=utf-8"}, w.Header()["Content-Type"])
+		})
+	}
+}
+
+func TestParseCondition(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		weak   bool
+		want   *endpoint.Condition
+	}{
+		{
+			"empty",
+			"",
+			false,
+			nil,
+		},
+		{
+			"any",
+			"*",
+			false,
+			&endpoint.Condition{Any: true},
+		},
+		{
+			"list",
+			`"1", "2"`,
+			false,
+			&endpoint.Condition{Versions: []int{1, 2}},
+		},
+		{
+			"weak",
+			`W/"1", "2"`,
+			true,
+			&endpoint.Condition{Versions: []int{1, 2}},
+		},
+		{
+			"strong",
+			`W/"1", "2"`,
+			false,
+			&endpoint.Condition{Versions: []int{2}},
+		},
+		{
+			"invalid",
+			`1, "a", "2`,
+			false,
+			&endpoint.Condition{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, parseCondition(tc.header, tc.weak))
+		})
+	}
+}
+
 func TestEncodeError(t *testing.T) {
 	testCases := []struct {
 		name string
 		err  error
 		want int
+		etag string
 	}{
 		{
 			"endpoint.ErrNotFound",
 			endpoint.ErrNotFound,
 			http.StatusNotFound,
+			"",
 		},
 		{
 			"endpoint.ErrBadRequest",
 			endpoint.ErrBadRequest,
 			http.StatusBadRequest,
+			"",
+		},
+		{
+			"endpoint.NotModifiedError",
+			endpoint.NotModifiedError{Version: 3},
+			http.StatusNotModified,
+			`"3"`,
 		},
 		{
 			"unhandled error",
 			errors.New("error"),
 			http.StatusInternalServerError,
+			"",
 		},
 		{
 			"nil error",
 			nil,
 			0,
+			"",
 		},
 	}
 
@@ -399,6 +1494,7 @@ func TestEncodeError(t *testing.T) {
 			} else {
 				fn()
 				require.Equal(t, tc.want, w.Code)
+				require.Equal(t, tc.etag, w.Header().Get("ETag"))
 			}
 		})
 	}
@@ -425,6 +1521,21 @@ func TestStatusCode(t *testing.T) {
 			errBadRequest,
 			http.StatusBadRequest,
 		},
+		{
+			"errUnsupportedMediaType",
+			errUnsupportedMediaType,
+			http.StatusUnsupportedMediaType,
+		},
+		{
+			"endpoint.NotModifiedError",
+			endpoint.NotModifiedError{Version: 2},
+			http.StatusNotModified,
+		},
+		{
+			"endpoint.ErrPreconditionFailed",
+			endpoint.ErrPreconditionFailed,
+			http.StatusPreconditionFailed,
+		},
 		{
 			"unhandled error",
 			errors.New("error"),
@@ -0,0 +1,48 @@
+package palindrome
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// batchChunkSize is the number of strings a worker of CheckBatch claims at a time, so workers rarely contend for the next index.
+const batchChunkSize = 64
+
+// CheckBatch checks each string of ss with c concurrently, and returns the results in the order of ss.
+// At most workers goroutines are used. If workers is less than 1, runtime.GOMAXPROCS(0) is used.
+// c must be safe for concurrent use, like the Checkers of this package.
+func CheckBatch(c Checker, ss []string, workers int) []bool {
+	res := make([]bool, len(ss))
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if chunks := (len(ss) + batchChunkSize - 1) / batchChunkSize; workers > chunks {
+		workers = chunks
+	}
+
+	var next int64
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for {
+				end := int(atomic.AddInt64(&next, batchChunkSize))
+				start := end - batchChunkSize
+				if start >= len(ss) {
+					return
+				}
+				if end > len(ss) {
+					end = len(ss)
+				}
+				// Each worker writes to distinct indices of res, so no lock is needed.
+				for i := start; i < end; i++ {
+					res[i] = c.IsPalindrome(ss[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return res
+}
@@ -0,0 +1,81 @@
+package palindrome
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBatch(t *testing.T) {
+	ss := randomLenientStrings(1000)
+	want := make([]bool, len(ss))
+	for i, s := range ss {
+		want[i] = IsPalindrome(s)
+	}
+
+	testCases := []struct {
+		name    string
+		ss      []string
+		workers int
+		want    []bool
+	}{
+		{
+			"empty",
+			nil,
+			4,
+			[]bool{},
+		},
+		{
+			"fewer strings than a chunk",
+			[]string{"racecar", "abc", "a toyota"},
+			4,
+			[]bool{true, false, true},
+		},
+		{
+			"single worker",
+			ss,
+			1,
+			want,
+		},
+		{
+			"many workers",
+			ss,
+			16,
+			want,
+		},
+		{
+			"default workers",
+			ss,
+			0,
+			want,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, CheckBatch(CheckerFunc(IsPalindrome), tc.ss, tc.workers))
+		})
+	}
+}
+
+func BenchmarkCheckBatch(b *testing.B) {
+	ss := make([]string, 100000)
+	for i := range ss {
+		ss[i] = benchmarkString
+	}
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, s := range ss {
+				IsPalindrome(s)
+			}
+		}
+	})
+	for _, workers := range []int{1, 4, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				CheckBatch(CheckerFunc(IsPalindrome), ss, workers)
+			}
+		})
+	}
+}
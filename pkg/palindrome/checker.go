@@ -0,0 +1,148 @@
+package palindrome
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrInvalidChecker is returned if a Checker is registered without a name or is nil.
+	ErrInvalidChecker = errors.New("invalid checker")
+
+	// ErrDuplicateChecker is returned if a Checker is registered under a name that is already in use.
+	ErrDuplicateChecker = errors.New("duplicate checker")
+)
+
+// Names of the Checkers registered by NewDefaultRegistry.
+const (
+	Strict   = "strict"
+	Lenient  = "lenient"
+	Unicode  = "unicode"
+	Grapheme = "grapheme"
+	Word     = "word"
+	Line     = "line"
+	DNA      = "dna"
+	Numeric  = "numeric"
+	Mirror   = "mirror"
+	Rotation = "rotation"
+)
+
+// Checker describes a strategy to check if a string is a palindrome.
+type Checker interface {
+	IsPalindrome(s string) bool
+}
+
+// CheckerFunc allows an ordinary function to be used as a Checker.
+type CheckerFunc func(s string) bool
+
+// IsPalindrome returns f(s).
+func (f CheckerFunc) IsPalindrome(s string) bool {
+	return f(s)
+}
+
+// Registry maps names to Checkers. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	checkers map[string]Checker
+}
+
+// NewRegistry returns a new empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		checkers: map[string]Checker{},
+	}
+}
+
+// NewDefaultRegistry returns a new Registry with the Checkers of this package registered.
+// o configures the Unicode and Grapheme Checkers.
+func NewDefaultRegistry(o UnicodeOptions) *Registry {
+	r := NewRegistry()
+	r.Register(Strict, unitChecker{
+		check: IsPalindromeStrict,
+		units: valid(strictUnits),
+	})
+	r.Register(Lenient, unitChecker{
+		check: IsPalindrome,
+		units: valid(lenientUnits),
+	})
+	r.Register(Unicode, unitChecker{
+		check: func(s string) bool {
+			return IsPalindromeUnicode(s, o)
+		},
+		units: func(s string) ([]string, error) {
+			return unicodeUnits(s, o), nil
+		},
+	})
+	r.Register(Grapheme, unitChecker{
+		check: func(s string) bool {
+			return IsPalindromeGraphemes(s, o)
+		},
+		units: func(s string) ([]string, error) {
+			return graphemeUnits(s, o), nil
+		},
+	})
+	r.Register(Word, unitChecker{
+		check: IsPalindromeWords,
+		units: valid(words),
+		sep:   " ",
+	})
+	r.Register(Line, unitChecker{
+		check: IsPalindromeLines,
+		units: valid(lines),
+		sep:   "\n",
+	})
+	r.Register(DNA, unitChecker{
+		check:   IsPalindromeDNA,
+		units:   dnaUnits,
+		partner: complement,
+	})
+	r.Register(Numeric, unitChecker{
+		check: IsPalindromeNumber,
+		units: numberUnits,
+	})
+	r.Register(Mirror, mirrorGlyphs)
+	r.Register(Rotation, rotationGlyphs)
+	return r
+}
+
+// valid returns units as the units function of a unitChecker, for Checkers that can compare any string.
+func valid(units func(s string) []string) func(s string) ([]string, error) {
+	return func(s string) ([]string, error) {
+		return units(s), nil
+	}
+}
+
+// Register registers c under name.
+func (r *Registry) Register(name string, c Checker) error {
+	if name == "" || c == nil {
+		return ErrInvalidChecker
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.checkers[name]; ok {
+		return ErrDuplicateChecker
+	}
+	r.checkers[name] = c
+	return nil
+}
+
+// Lookup returns the Checker registered under name.
+func (r *Registry) Lookup(name string) (Checker, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.checkers[name]
+	return c, ok
+}
+
+// Names returns the sorted names of the registered Checkers.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.checkers))
+	for name := range r.checkers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,174 @@
+package palindrome
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistryRegister(t *testing.T) {
+	testCases := []struct {
+		name    string
+		checker string
+		c       Checker
+		errMsg  string
+	}{
+		{
+			"success",
+			"custom",
+			CheckerFunc(IsPalindromeStrict),
+			"",
+		},
+		{
+			"empty name",
+			"",
+			CheckerFunc(IsPalindromeStrict),
+			ErrInvalidChecker.Error(),
+		},
+		{
+			"nil checker",
+			"custom",
+			nil,
+			ErrInvalidChecker.Error(),
+		},
+		{
+			"ErrDuplicateChecker",
+			Strict,
+			CheckerFunc(IsPalindromeStrict),
+			ErrDuplicateChecker.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewDefaultRegistry(UnicodeOptions{})
+			err := r.Register(tc.checker, tc.c)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				c, ok := r.Lookup(tc.checker)
+				require.True(t, ok)
+				require.NotNil(t, c)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestRegistryLookup(t *testing.T) {
+	r := NewDefaultRegistry(UnicodeOptions{StripDiacritics: true})
+	r.Register("reversed-words", CheckerFunc(func(s string) bool {
+		return IsPalindromeWords(strings.ToLower(s))
+	}))
+
+	testCases := []struct {
+		name    string
+		checker string
+		s       string
+		want    bool
+		ok      bool
+	}{
+		{
+			"strict",
+			Strict,
+			"a toyota",
+			false,
+			true,
+		},
+		{
+			"lenient",
+			Lenient,
+			"a toyota",
+			true,
+			true,
+		},
+		{
+			"unicode",
+			Unicode,
+			"Ésope reste ici et se repose",
+			true,
+			true,
+		},
+		{
+			"grapheme",
+			Grapheme,
+			"Ésope reste ici et se repose",
+			true,
+			true,
+		},
+		{
+			"word",
+			Word,
+			"Fall leaves after leaves fall",
+			true,
+			true,
+		},
+		{
+			"line",
+			Line,
+			"a\nb\na",
+			true,
+			true,
+		},
+		{
+			"dna",
+			DNA,
+			"gaattc",
+			true,
+			true,
+		},
+		{
+			"numeric",
+			Numeric,
+			"585",
+			true,
+			true,
+		},
+		{
+			"mirror",
+			Mirror,
+			"(xox)",
+			true,
+			true,
+		},
+		{
+			"rotation",
+			Rotation,
+			"SWIMS",
+			true,
+			true,
+		},
+		{
+			"custom",
+			"reversed-words",
+			"Fall leaves after leaves fall",
+			true,
+			true,
+		},
+		{
+			"not registered",
+			"invalid",
+			"",
+			false,
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, ok := r.Lookup(tc.checker)
+			require.Equal(t, tc.ok, ok)
+			if ok {
+				require.Equal(t, tc.want, c.IsPalindrome(tc.s))
+			}
+		})
+	}
+}
+
+func TestRegistryNames(t *testing.T) {
+	r := NewDefaultRegistry(UnicodeOptions{})
+	require.Equal(t, []string{DNA, Grapheme, Lenient, Line, Mirror, Numeric, Rotation, Strict, Unicode, Word}, r.Names())
+	require.Empty(t, NewRegistry().Names())
+}
@@ -0,0 +1,212 @@
+package palindrome
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrTooLong is returned if a string is longer than MaxDistanceLength.
+	ErrTooLong = errors.New("too long")
+)
+
+// MaxDistanceLength is the maximum number of runes accepted by Distance, and of units accepted by a Repairer, which use quadratic time and memory.
+const MaxDistanceLength = 2048
+
+// Repair represents the edits needed to make a string a palindrome.
+type Repair struct {
+	// Distance is the minimum number of edits.
+	Distance int
+	// Suggestion is a palindrome Distance edits away from the string.
+	Suggestion string
+}
+
+// Repairer is implemented by Checkers that compute the distance of a string to a palindrome in the units they compare, so the distance is 0 exactly if IsPalindrome returns true.
+// The Suggestion is made of the compared units, so it is normalized like them: for example, the lenient Checker suggests "amanaplanacanalpanama".
+type Repairer interface {
+	Checker
+	Repair(s string, substitutions bool) (Repair, error)
+}
+
+// Distance returns the minimum number of rune insertions or deletions needed to make s a palindrome, and one such palindrome.
+// If substitutions is true, replacing a rune also counts as a single edit.
+// Runes are compared as-is, and the suggestion keeps every rune of s, inserting or replacing runes instead of deleting them.
+func Distance(s string, substitutions bool) (Repair, error) {
+	if utf8.RuneCountInString(s) > MaxDistanceLength {
+		return Repair{}, ErrTooLong
+	}
+	units := make([]string, 0, len(s))
+	for _, r := range s {
+		units = append(units, string(r))
+	}
+	return repairUnits(units, identity, "", substitutions)
+}
+
+// unitChecker is a Checker that compares the units of a string, and implements Repairer by editing them.
+type unitChecker struct {
+	check func(s string) bool
+	// units returns the units compared by check, or an error if check returns false because s cannot be compared.
+	units func(s string) ([]string, error)
+	// partner returns the unit that must be at the mirrored position of a unit. Units are their own partners if it is nil.
+	partner func(u string) (string, bool)
+	// sep separates the units of a suggestion.
+	sep string
+}
+
+func (c unitChecker) IsPalindrome(s string) bool {
+	return c.check(s)
+}
+
+// Repair edits the units of s. The suggestion is empty if it is not valid UTF-8, which happens if multi-byte runes are compared byte by byte.
+func (c unitChecker) Repair(s string, substitutions bool) (Repair, error) {
+	units, err := c.units(s)
+	if err != nil {
+		return Repair{}, err
+	}
+	partner := c.partner
+	if partner == nil {
+		partner = identity
+	}
+	r, err := repairUnits(units, partner, c.sep, substitutions)
+	if err != nil {
+		return Repair{}, err
+	}
+	if !utf8.ValidString(r.Suggestion) {
+		r.Suggestion = ""
+	}
+	return r, nil
+}
+
+// Repair edits the runes of s, replacing runes by their counterparts in t. A rune without a counterpart can only be deleted. It implements Repairer.
+func (t GlyphTable) Repair(s string, substitutions bool) (Repair, error) {
+	if utf8.RuneCountInString(s) > MaxDistanceLength {
+		return Repair{}, ErrTooLong
+	}
+	units := make([]string, 0, len(s))
+	for _, r := range s {
+		units = append(units, string(r))
+	}
+	return repairUnits(units, func(u string) (string, bool) {
+		r, _ := utf8.DecodeRuneInString(u)
+		g, ok := t[r]
+		return string(g), ok
+	}, "", substitutions)
+}
+
+func identity(u string) (string, bool) {
+	return u, true
+}
+
+// repairUnits is like Distance, but edits units instead of runes, and a unit must be mirrored by its partner instead of itself.
+// partner must be an involution, so the partner of a partner is the unit itself. A unit without a partner cannot be mirrored, so it is deleted.
+// The units of the suggestion are joined with sep.
+func repairUnits(units []string, partner func(u string) (string, bool), sep string, substitutions bool) (Repair, error) {
+	n := len(units)
+	if n > MaxDistanceLength {
+		return Repair{}, ErrTooLong
+	}
+	if n == 0 {
+		return Repair{}, nil
+	}
+
+	// pairs reports whether units[i] and units[j] can be at mirrored positions.
+	pairs := func(i, j int) bool {
+		p, ok := partner(units[i])
+		return ok && p == units[j]
+	}
+	// substitutable reports whether one of units[i] and units[j] can be replaced by the partner of the other.
+	substitutable := func(i, j int) bool {
+		_, ok := partner(units[i])
+		if !ok {
+			_, ok = partner(units[j])
+		}
+		return substitutions && ok
+	}
+
+	// d(i, j) is the distance of units[i:j+1]. Empty ranges are palindromes, and a single unit is one if it is its own partner.
+	dp := make([]uint16, n*n)
+	d := func(i, j int) int {
+		if i > j {
+			return 0
+		}
+		return int(dp[i*n+j])
+	}
+	for i := 0; i < n; i++ {
+		if !pairs(i, i) {
+			dp[i*n+i] = 1
+		}
+	}
+	for l := 2; l <= n; l++ {
+		for i := 0; i+l <= n; i++ {
+			j := i + l - 1
+			var v int
+			if pairs(i, j) {
+				v = d(i+1, j-1)
+			} else {
+				v = d(i+1, j)
+				if w := d(i, j-1); w < v {
+					v = w
+				}
+				if w := d(i+1, j-1); substitutable(i, j) && w < v {
+					v = w
+				}
+				v++
+			}
+			dp[i*n+j] = uint16(v)
+		}
+	}
+
+	// left is the first half of the suggestion, which is mirrored by the partners of its units.
+	var left []string
+	var middle []string
+	keep := func(u string) {
+		if _, ok := partner(u); ok {
+			left = append(left, u)
+		}
+	}
+	mirror := func(u string) {
+		if p, ok := partner(u); ok {
+			left = append(left, p)
+		}
+	}
+	i, j := 0, n-1
+	for i <= j {
+		switch {
+		case i == j && pairs(i, i):
+			middle = append(middle, units[i])
+			i++
+		case i == j:
+			keep(units[i])
+			i++
+		case pairs(i, j):
+			left = append(left, units[i])
+			i, j = i+1, j-1
+		case substitutable(i, j) && d(i+1, j-1)+1 == d(i, j):
+			if _, ok := partner(units[i]); ok {
+				left = append(left, units[i])
+			} else {
+				mirror(units[j])
+			}
+			i, j = i+1, j-1
+		case d(i+1, j)+1 == d(i, j):
+			keep(units[i])
+			i++
+		default:
+			mirror(units[j])
+			j--
+		}
+	}
+
+	res := make([]string, 0, 2*len(left)+len(middle))
+	res = append(res, left...)
+	res = append(res, middle...)
+	for k := len(left) - 1; k >= 0; k-- {
+		p, _ := partner(left[k])
+		res = append(res, p)
+	}
+	return Repair{
+		Distance:   d(0, n-1),
+		Suggestion: strings.Join(res, sep),
+	}, nil
+}
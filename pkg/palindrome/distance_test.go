@@ -0,0 +1,168 @@
+package palindrome
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDistance(t *testing.T) {
+	testCases := []struct {
+		name          string
+		s             string
+		substitutions bool
+		want          Repair
+		errMsg        string
+	}{
+		{
+			"empty string",
+			"",
+			false,
+			Repair{},
+			"",
+		},
+		{
+			"palindrome",
+			"racecar",
+			false,
+			Repair{0, "racecar"},
+			"",
+		},
+		{
+			"one insertion",
+			"racecr",
+			false,
+			Repair{1, "racecar"},
+			"",
+		},
+		{
+			"insertions",
+			"ab",
+			false,
+			Repair{1, "aba"},
+			"",
+		},
+		{
+			"substitution",
+			"abcdeba",
+			true,
+			Repair{1, "abcdcba"},
+			"",
+		},
+		{
+			"substitution without substitutions",
+			"abcdeba",
+			false,
+			Repair{2, "abcdedcba"},
+			"",
+		},
+		{
+			"runes",
+			"été",
+			false,
+			Repair{0, "été"},
+			"",
+		},
+		{
+			"ErrTooLong",
+			strings.Repeat("ab", MaxDistanceLength),
+			false,
+			Repair{},
+			ErrTooLong.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r, err := Distance(tc.s, tc.substitutions)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, r)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, r)
+			}
+		})
+	}
+}
+
+func TestDistanceSuggestion(t *testing.T) {
+	for _, s := range []string{"abc", "banana", "google", "a toyota", "palindrome", "xyzzyx!"} {
+		for _, substitutions := range []bool{false, true} {
+			r, err := Distance(s, substitutions)
+			require.NoError(t, err)
+			require.True(t, IsPalindromeStrict(r.Suggestion), s)
+			if !substitutions {
+				require.Equal(t, len([]rune(s))+r.Distance, len([]rune(r.Suggestion)), s)
+			}
+		}
+	}
+}
+
+func TestRepair(t *testing.T) {
+	testCases := []struct {
+		name          string
+		checker       string
+		s             string
+		substitutions bool
+		want          Repair
+	}{
+		{"strict", Strict, "racecr", false, Repair{1, "racecar"}},
+		{"strict bytes", Strict, "été", false, Repair{2, ""}},
+		{"lenient", Lenient, "A man, a plan, a canal: Panama", false, Repair{0, "amanaplanacanalpanama"}},
+		{"lenient insertion", Lenient, "Race car!", false, Repair{0, "racecar"}},
+		{"unicode", Unicode, "Ésope reste ici et se repose", false, Repair{2, "ÉESOPERESTEICIETSEREPOSEÉ"}},
+		{"unicode palindrome", Unicode, "А роза упала на лапу Азора", false, Repair{0, "АРОЗАУПАЛАНАЛАПУАЗОРА"}},
+		{"grapheme", Grapheme, "🇫🇷 a 🇩🇪", true, Repair{1, "🇫🇷A🇫🇷"}},
+		{"word", Word, "Fall leaves after leaves fall", false, Repair{0, "FALL LEAVES AFTER LEAVES FALL"}},
+		{"word insertion", Word, "one two", false, Repair{1, "ONE TWO ONE"}},
+		{"line", Line, "Roses\nare red\nroses", false, Repair{0, "ROSES\nARE RED\nROSES"}},
+		{"dna", DNA, "GAATTC", false, Repair{0, "GAATTC"}},
+		{"dna middle", DNA, "GAC", false, Repair{1, "GATC"}},
+		{"dna substitution", DNA, "GAATTG", true, Repair{1, "GAATTC"}},
+		{"numeric", Numeric, "0123", false, Repair{2, "12321"}},
+		{"mirror", Mirror, "bid", false, Repair{0, "bid"}},
+		{"mirror deletion", Mirror, "bike", false, Repair{3, "bid"}},
+		{"rotation", Rotation, "pod", false, Repair{0, "pod"}},
+	}
+
+	checkers := NewDefaultRegistry(UnicodeOptions{})
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, ok := checkers.Lookup(tc.checker)
+			require.True(t, ok)
+			r, err := c.(Repairer).Repair(tc.s, tc.substitutions)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, r)
+			require.Equal(t, c.IsPalindrome(tc.s), r.Distance == 0)
+			if r.Suggestion != "" {
+				require.True(t, c.IsPalindrome(r.Suggestion), r.Suggestion)
+			}
+		})
+	}
+}
+
+func TestRepairInvalid(t *testing.T) {
+	checkers := NewDefaultRegistry(UnicodeOptions{})
+	testCases := []struct {
+		name    string
+		checker string
+		s       string
+		err     error
+	}{
+		{"empty dna", DNA, "", ErrInvalidNucleotide},
+		{"invalid dna", DNA, "GAXTC", ErrInvalidNucleotide},
+		{"invalid number", Numeric, "12a", ErrInvalidNumber},
+		{"too long", Lenient, strings.Repeat("ab", MaxDistanceLength), ErrTooLong},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, _ := checkers.Lookup(tc.checker)
+			_, err := c.(Repairer).Repair(tc.s, false)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
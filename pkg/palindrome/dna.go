@@ -0,0 +1,161 @@
+package palindrome
+
+import (
+	"errors"
+	"sort"
+)
+
+// ErrInvalidNucleotide is returned if a sequence contains a character that is not an IUPAC nucleotide code.
+var ErrInvalidNucleotide = errors.New("invalid nucleotide")
+
+// DefaultMinSiteLength is the minimum length of the sites found by DNASites if no minimum length is given.
+// Most restriction enzymes recognize sites of 4 to 8 nucleotides.
+const DefaultMinSiteLength = 4
+
+// complements maps the upper case IUPAC nucleotide codes to their complements.
+// U is read as T, so RNA sequences are compared like DNA sequences.
+var complements = [256]byte{
+	'A': 'T',
+	'T': 'A',
+	'U': 'A',
+	'C': 'G',
+	'G': 'C',
+	'R': 'Y',
+	'Y': 'R',
+	'S': 'S',
+	'W': 'W',
+	'K': 'M',
+	'M': 'K',
+	'B': 'V',
+	'V': 'B',
+	'D': 'H',
+	'H': 'D',
+	'N': 'N',
+}
+
+// Site represents a reverse complement palindromic site of a sequence.
+// Offsets are measured in nucleotides, ignoring whitespace.
+type Site struct {
+	Sequence string
+	Start    int
+	End      int
+	Length   int
+}
+
+// nucleotides returns the upper case nucleotides of s without whitespace, or ErrInvalidNucleotide.
+func nucleotides(s string) ([]byte, error) {
+	seq := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case ' ', '\t', '\n', '\r':
+			continue
+		}
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		if complements[c] == 0 {
+			return nil, ErrInvalidNucleotide
+		}
+		if c == 'U' {
+			c = 'T'
+		}
+		seq = append(seq, c)
+	}
+	return seq, nil
+}
+
+// ReverseComplement returns the reverse complement of the sequence s in upper case, ignoring whitespace.
+func ReverseComplement(s string) (string, error) {
+	seq, err := nucleotides(s)
+	if err != nil {
+		return "", err
+	}
+	rc := make([]byte, len(seq))
+	for i, c := range seq {
+		rc[len(seq)-1-i] = complements[c]
+	}
+	return string(rc), nil
+}
+
+// IsPalindromeDNA checks if the sequence s is equal to its reverse complement, like the restriction site GAATTC.
+// Ambiguity codes are complemented like any other code, so GANTC is a palindrome but GAATC is not.
+// It returns false if s is empty or is not a valid sequence.
+func IsPalindromeDNA(s string) bool {
+	seq, err := nucleotides(s)
+	if err != nil || len(seq) == 0 {
+		return false
+	}
+	for i, j := 0, len(seq)-1; i <= j; i, j = i+1, j-1 {
+		if seq[i] != complements[seq[j]] {
+			return false
+		}
+	}
+	return true
+}
+
+// dnaUnits returns the nucleotides of s compared by IsPalindromeDNA. An empty sequence is not a palindrome, but cannot be repaired, so it is invalid.
+func dnaUnits(s string) ([]string, error) {
+	seq, err := nucleotides(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(seq) == 0 {
+		return nil, ErrInvalidNucleotide
+	}
+	units := make([]string, len(seq))
+	for i := range seq {
+		units[i] = string(seq[i : i+1])
+	}
+	return units, nil
+}
+
+// complement returns the complement of the nucleotide u, which is the partner of u in a reverse complement palindrome.
+func complement(u string) (string, bool) {
+	c := complements[u[0]]
+	return string(c), c != 0
+}
+
+// DNASites returns the reverse complement palindromic sites of the sequence s that are at least minLength nucleotides long, ordered by position, and longer sites first.
+// Only the longest site around each center is returned, since the sites nested in it share its center.
+// If minLength is less than 1, DefaultMinSiteLength is used.
+func DNASites(s string, minLength int) ([]Site, error) {
+	seq, err := nucleotides(s)
+	if err != nil {
+		return nil, err
+	}
+	if minLength < 1 {
+		minLength = DefaultMinSiteLength
+	}
+	var sites []Site
+	// Center c lies between seq[c/2-1] and seq[c/2] if c is even, and on seq[c/2] if c is odd.
+	for c := 0; c < 2*len(seq); c++ {
+		i, j := c/2-1, c/2
+		if c%2 == 1 {
+			if seq[c/2] != complements[seq[c/2]] {
+				continue
+			}
+			i, j = c/2-1, c/2+1
+		}
+		for i >= 0 && j < len(seq) && seq[i] == complements[seq[j]] {
+			i--
+			j++
+		}
+		start, end := i+1, j
+		if end-start >= minLength {
+			sites = append(sites, Site{
+				Sequence: string(seq[start:end]),
+				Start:    start,
+				End:      end,
+				Length:   end - start,
+			})
+		}
+	}
+	sort.Slice(sites, func(i, j int) bool {
+		if sites[i].Start != sites[j].Start {
+			return sites[i].Start < sites[j].Start
+		}
+		return sites[i].Length > sites[j].Length
+	})
+	return sites, nil
+}
@@ -0,0 +1,182 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReverseComplement(t *testing.T) {
+	testCases := []struct {
+		name   string
+		s      string
+		want   string
+		errMsg string
+	}{
+		{
+			"empty",
+			"",
+			"",
+			"",
+		},
+		{
+			"dna",
+			"ATGC",
+			"GCAT",
+			"",
+		},
+		{
+			"rna",
+			"aug c",
+			"GCAT",
+			"",
+		},
+		{
+			"ambiguity codes",
+			"RYSWKMBVDHN",
+			"NDHBVKMWSRY",
+			"",
+		},
+		{
+			"ErrInvalidNucleotide",
+			"ATGX",
+			"",
+			ErrInvalidNucleotide.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rc, err := ReverseComplement(tc.s)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, rc)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestIsPalindromeDNA(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"empty", "", false},
+		{"EcoRI", "GAATTC", true},
+		{"lower case", "gaattc", true},
+		{"rna", "GAAUUC", true},
+		{"whitespace", "GAA\nTTC", true},
+		{"HinfI", "GANTC", true},
+		{"odd length", "GAATC", false},
+		{"self complementary center", "GGSCC", true},
+		{"ambiguity codes", "RGATCY", true},
+		{"not a palindrome", "GAATTG", false},
+		{"reversed", "ACCA", false},
+		{"invalid", "GAXTTC", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, IsPalindromeDNA(tc.s))
+		})
+	}
+}
+
+func TestDNASites(t *testing.T) {
+	testCases := []struct {
+		name      string
+		s         string
+		minLength int
+		want      []Site
+		errMsg    string
+	}{
+		{
+			"empty",
+			"",
+			0,
+			nil,
+			"",
+		},
+		{
+			"EcoRI and BamHI",
+			"aaGAATTCgtGGATCCt",
+			6,
+			[]Site{
+				{"GAATTC", 2, 8, 6},
+				{"GGATCC", 10, 16, 6},
+			},
+			"",
+		},
+		{
+			"default minimum length",
+			"TTGAATTCTT",
+			0,
+			[]Site{
+				{"GAATTC", 2, 8, 6},
+			},
+			"",
+		},
+		{
+			"nested sites",
+			"GAATTC",
+			2,
+			[]Site{
+				{"GAATTC", 0, 6, 6},
+			},
+			"",
+		},
+		{
+			"overlapping sites",
+			"ATATAT",
+			4,
+			[]Site{
+				{"ATATAT", 0, 6, 6},
+				{"ATAT", 0, 4, 4},
+				{"ATAT", 2, 6, 4},
+			},
+			"",
+		},
+		{
+			"odd length",
+			"AGGSCCA",
+			4,
+			[]Site{
+				{"GGSCC", 1, 6, 5},
+			},
+			"",
+		},
+		{
+			"offsets ignore whitespace",
+			"AA GAA\nTTC",
+			6,
+			[]Site{
+				{"GAATTC", 2, 8, 6},
+			},
+			"",
+		},
+		{
+			"ErrInvalidNucleotide",
+			"GAATTC!",
+			4,
+			nil,
+			ErrInvalidNucleotide.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sites, err := DNASites(tc.s, tc.minLength)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, sites)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package palindrome
+
+import "sort"
+
+// PalindromicSubstring represents a distinct palindromic substring of a string and its occurrences.
+// Offsets are measured in runes.
+type PalindromicSubstring struct {
+	Text   string
+	Length int
+	// Count is the number of occurrences of Text, including overlapping ones.
+	Count int
+	// Start is the offset of the first occurrence of Text.
+	Start int
+}
+
+// eertreeNode represents a distinct palindrome in an eertree.
+type eertreeNode struct {
+	length int
+	// link is the node of the longest proper palindromic suffix.
+	link int
+	next map[rune]int
+	// count is the number of positions where the palindrome is the longest palindromic suffix, until the counts are propagated along the links.
+	count int
+	// end is the offset after the first occurrence.
+	end int
+}
+
+// PalindromicSubstrings returns the distinct palindromic substrings of s that are at least minLength runes long, longest first and then by first occurrence.
+// Runes are compared as-is, like IsPalindromeStrict compares bytes.
+// It builds a palindromic tree (eertree), which runs in linear time for a fixed alphabet, and the Text of each substring shares the memory of s.
+func PalindromicSubstrings(s string, minLength int) []PalindromicSubstring {
+	rs := []rune(s)
+	// offsets[i] is the byte offset of rs[i] in s.
+	offsets := make([]int, 0, len(rs)+1)
+	for i := range s {
+		offsets = append(offsets, i)
+	}
+	offsets = append(offsets, len(s))
+
+	// Node 0 is the imaginary root of length -1, and node 1 is the root of the empty palindrome.
+	nodes := []eertreeNode{
+		{length: -1, link: 0, next: map[rune]int{}},
+		{length: 0, link: 0, next: map[rune]int{}},
+	}
+	// suffix returns the longest palindromic suffix of rs[:i] reachable from node that can be extended by rs[i].
+	suffix := func(node, i int) int {
+		for {
+			l := nodes[node].length
+			if i-1-l >= 0 && rs[i-1-l] == rs[i] {
+				return node
+			}
+			node = nodes[node].link
+		}
+	}
+
+	last := 1
+	for i, r := range rs {
+		cur := suffix(last, i)
+		if next, ok := nodes[cur].next[r]; ok {
+			last = next
+			nodes[last].count++
+			continue
+		}
+		node := eertreeNode{
+			length: nodes[cur].length + 2,
+			link:   1,
+			next:   map[rune]int{},
+			count:  1,
+			end:    i + 1,
+		}
+		if node.length > 1 {
+			node.link = nodes[suffix(nodes[cur].link, i)].next[r]
+		}
+		nodes = append(nodes, node)
+		last = len(nodes) - 1
+		nodes[cur].next[r] = last
+	}
+
+	// Every occurrence of a palindrome is also an occurrence of its palindromic suffixes, and links always point to earlier nodes.
+	for k := len(nodes) - 1; k > 1; k-- {
+		nodes[nodes[k].link].count += nodes[k].count
+	}
+
+	var res []PalindromicSubstring
+	for _, node := range nodes[2:] {
+		if node.length < minLength {
+			continue
+		}
+		start := node.end - node.length
+		res = append(res, PalindromicSubstring{
+			Text:   s[offsets[start]:offsets[node.end]],
+			Length: node.length,
+			Count:  node.count,
+			Start:  start,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Length != res[j].Length {
+			return res[i].Length > res[j].Length
+		}
+		return res[i].Start < res[j].Start
+	})
+	return res
+}
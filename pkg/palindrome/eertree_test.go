@@ -0,0 +1,119 @@
+package palindrome
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPalindromicSubstrings(t *testing.T) {
+	testCases := []struct {
+		name      string
+		s         string
+		minLength int
+		want      []PalindromicSubstring
+	}{
+		{
+			"empty",
+			"",
+			0,
+			nil,
+		},
+		{
+			"single rune",
+			"a",
+			0,
+			[]PalindromicSubstring{
+				{"a", 1, 1, 0},
+			},
+		},
+		{
+			"abacaba",
+			"abacaba",
+			0,
+			[]PalindromicSubstring{
+				{"abacaba", 7, 1, 0},
+				{"bacab", 5, 1, 1},
+				{"aba", 3, 2, 0},
+				{"aca", 3, 1, 2},
+				{"a", 1, 4, 0},
+				{"b", 1, 2, 1},
+				{"c", 1, 1, 3},
+			},
+		},
+		{
+			"overlapping occurrences",
+			"aaaa",
+			2,
+			[]PalindromicSubstring{
+				{"aaaa", 4, 1, 0},
+				{"aaa", 3, 2, 0},
+				{"aa", 2, 3, 0},
+			},
+		},
+		{
+			"minimum length",
+			"xabbay xy",
+			3,
+			[]PalindromicSubstring{
+				{"abba", 4, 1, 1},
+			},
+		},
+		{
+			"multibyte runes",
+			"été",
+			2,
+			[]PalindromicSubstring{
+				{"été", 3, 1, 0},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, PalindromicSubstrings(tc.s, tc.minLength))
+		})
+	}
+}
+
+func TestPalindromicSubstringsRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for n := 0; n < 200; n++ {
+		rs := make([]rune, r.Intn(30))
+		for i := range rs {
+			rs[i] = rune('a' + r.Intn(3))
+		}
+		s := string(rs)
+		require.Equal(t, naivePalindromicSubstrings(s), PalindromicSubstrings(s, 0), s)
+	}
+}
+
+// naivePalindromicSubstrings counts the palindromic substrings of s by checking every substring.
+func naivePalindromicSubstrings(s string) []PalindromicSubstring {
+	rs := []rune(s)
+	index := map[string]int{}
+	var res []PalindromicSubstring
+	for i := range rs {
+		for j := i + 1; j <= len(rs); j++ {
+			sub := string(rs[i:j])
+			if !IsPalindromeStrict(sub) {
+				continue
+			}
+			if k, ok := index[sub]; ok {
+				res[k].Count++
+				continue
+			}
+			index[sub] = len(res)
+			res = append(res, PalindromicSubstring{sub, j - i, 1, i})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Length != res[j].Length {
+			return res[i].Length > res[j].Length
+		}
+		return res[i].Start < res[j].Start
+	})
+	return res
+}
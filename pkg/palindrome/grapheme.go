@@ -0,0 +1,237 @@
+package palindrome
+
+import (
+	"strings"
+	"unicode"
+)
+
+// graphemeProperty is the Grapheme_Cluster_Break property of a rune.
+type graphemeProperty int
+
+const (
+	gpOther graphemeProperty = iota
+	gpCR
+	gpLF
+	gpControl
+	gpExtend
+	gpZWJ
+	gpSpacingMark
+	gpRegionalIndicator
+	gpL
+	gpV
+	gpT
+	gpLV
+	gpLVT
+)
+
+// extendedPictographic approximates the Extended_Pictographic property from the Unicode emoji data.
+var extendedPictographic = &unicode.RangeTable{
+	R16: []unicode.Range16{
+		{Lo: 0x00a9, Hi: 0x00a9, Stride: 1},
+		{Lo: 0x00ae, Hi: 0x00ae, Stride: 1},
+		{Lo: 0x203c, Hi: 0x203c, Stride: 1},
+		{Lo: 0x2049, Hi: 0x2049, Stride: 1},
+		{Lo: 0x2122, Hi: 0x2122, Stride: 1},
+		{Lo: 0x2139, Hi: 0x2139, Stride: 1},
+		{Lo: 0x2194, Hi: 0x2199, Stride: 1},
+		{Lo: 0x21a9, Hi: 0x21aa, Stride: 1},
+		{Lo: 0x231a, Hi: 0x231b, Stride: 1},
+		{Lo: 0x2328, Hi: 0x2328, Stride: 1},
+		{Lo: 0x2388, Hi: 0x2388, Stride: 1},
+		{Lo: 0x23cf, Hi: 0x23cf, Stride: 1},
+		{Lo: 0x23e9, Hi: 0x23f3, Stride: 1},
+		{Lo: 0x23f8, Hi: 0x23fa, Stride: 1},
+		{Lo: 0x24c2, Hi: 0x24c2, Stride: 1},
+		{Lo: 0x25aa, Hi: 0x25ab, Stride: 1},
+		{Lo: 0x25b6, Hi: 0x25b6, Stride: 1},
+		{Lo: 0x25c0, Hi: 0x25c0, Stride: 1},
+		{Lo: 0x25fb, Hi: 0x25fe, Stride: 1},
+		{Lo: 0x2600, Hi: 0x2605, Stride: 1},
+		{Lo: 0x2607, Hi: 0x2612, Stride: 1},
+		{Lo: 0x2614, Hi: 0x2685, Stride: 1},
+		{Lo: 0x2690, Hi: 0x2705, Stride: 1},
+		{Lo: 0x2708, Hi: 0x2712, Stride: 1},
+		{Lo: 0x2714, Hi: 0x2714, Stride: 1},
+		{Lo: 0x2716, Hi: 0x2716, Stride: 1},
+		{Lo: 0x271d, Hi: 0x271d, Stride: 1},
+		{Lo: 0x2721, Hi: 0x2721, Stride: 1},
+		{Lo: 0x2728, Hi: 0x2728, Stride: 1},
+		{Lo: 0x2733, Hi: 0x2734, Stride: 1},
+		{Lo: 0x2744, Hi: 0x2744, Stride: 1},
+		{Lo: 0x2747, Hi: 0x2747, Stride: 1},
+		{Lo: 0x274c, Hi: 0x274c, Stride: 1},
+		{Lo: 0x274e, Hi: 0x274e, Stride: 1},
+		{Lo: 0x2753, Hi: 0x2755, Stride: 1},
+		{Lo: 0x2757, Hi: 0x2757, Stride: 1},
+		{Lo: 0x2763, Hi: 0x2767, Stride: 1},
+		{Lo: 0x2795, Hi: 0x2797, Stride: 1},
+		{Lo: 0x27a1, Hi: 0x27a1, Stride: 1},
+		{Lo: 0x27b0, Hi: 0x27b0, Stride: 1},
+		{Lo: 0x27bf, Hi: 0x27bf, Stride: 1},
+		{Lo: 0x2934, Hi: 0x2935, Stride: 1},
+		{Lo: 0x2b05, Hi: 0x2b07, Stride: 1},
+		{Lo: 0x2b1b, Hi: 0x2b1c, Stride: 1},
+		{Lo: 0x2b50, Hi: 0x2b50, Stride: 1},
+		{Lo: 0x2b55, Hi: 0x2b55, Stride: 1},
+		{Lo: 0x3030, Hi: 0x3030, Stride: 1},
+		{Lo: 0x303d, Hi: 0x303d, Stride: 1},
+		{Lo: 0x3297, Hi: 0x3297, Stride: 1},
+		{Lo: 0x3299, Hi: 0x3299, Stride: 1},
+	},
+	R32: []unicode.Range32{
+		{Lo: 0x1f000, Hi: 0x1f0ff, Stride: 1},
+		{Lo: 0x1f10d, Hi: 0x1f10f, Stride: 1},
+		{Lo: 0x1f12f, Hi: 0x1f12f, Stride: 1},
+		{Lo: 0x1f16c, Hi: 0x1f171, Stride: 1},
+		{Lo: 0x1f17e, Hi: 0x1f17f, Stride: 1},
+		{Lo: 0x1f18e, Hi: 0x1f18e, Stride: 1},
+		{Lo: 0x1f191, Hi: 0x1f19a, Stride: 1},
+		{Lo: 0x1f1ad, Hi: 0x1f1e5, Stride: 1},
+		{Lo: 0x1f201, Hi: 0x1f20f, Stride: 1},
+		{Lo: 0x1f21a, Hi: 0x1f21a, Stride: 1},
+		{Lo: 0x1f22f, Hi: 0x1f22f, Stride: 1},
+		{Lo: 0x1f232, Hi: 0x1f23a, Stride: 1},
+		{Lo: 0x1f23c, Hi: 0x1f23f, Stride: 1},
+		{Lo: 0x1f249, Hi: 0x1f3fa, Stride: 1},
+		{Lo: 0x1f400, Hi: 0x1f53d, Stride: 1},
+		{Lo: 0x1f546, Hi: 0x1f64f, Stride: 1},
+		{Lo: 0x1f680, Hi: 0x1f6ff, Stride: 1},
+		{Lo: 0x1f774, Hi: 0x1f77f, Stride: 1},
+		{Lo: 0x1f7d5, Hi: 0x1f7ff, Stride: 1},
+		{Lo: 0x1f80c, Hi: 0x1f80f, Stride: 1},
+		{Lo: 0x1f848, Hi: 0x1f84f, Stride: 1},
+		{Lo: 0x1f85a, Hi: 0x1f85f, Stride: 1},
+		{Lo: 0x1f888, Hi: 0x1f88f, Stride: 1},
+		{Lo: 0x1f8ae, Hi: 0x1f8ff, Stride: 1},
+		{Lo: 0x1f90c, Hi: 0x1f93a, Stride: 1},
+		{Lo: 0x1f93c, Hi: 0x1f945, Stride: 1},
+		{Lo: 0x1f947, Hi: 0x1faff, Stride: 1},
+		{Lo: 0x1fc00, Hi: 0x1fffd, Stride: 1},
+	},
+}
+
+// graphemePropertyOf returns the Grapheme_Cluster_Break property of r.
+// Prepend characters are treated as Other.
+func graphemePropertyOf(r rune) graphemeProperty {
+	switch {
+	case r == '\r':
+		return gpCR
+	case r == '\n':
+		return gpLF
+	case r == 0x200d:
+		return gpZWJ
+	case r == 0x200c,
+		r >= 0x1f3fb && r <= 0x1f3ff,
+		r >= 0xe0020 && r <= 0xe007f,
+		unicode.In(r, unicode.Mn, unicode.Me):
+		return gpExtend
+	case unicode.In(r, unicode.Cc, unicode.Cf, unicode.Zl, unicode.Zp):
+		return gpControl
+	case unicode.Is(unicode.Mc, r):
+		return gpSpacingMark
+	case r >= 0x1f1e6 && r <= 0x1f1ff:
+		return gpRegionalIndicator
+	case r >= 0x1100 && r <= 0x115f, r >= 0xa960 && r <= 0xa97c:
+		return gpL
+	case r >= 0x1160 && r <= 0x11a7, r >= 0xd7b0 && r <= 0xd7c6:
+		return gpV
+	case r >= 0x11a8 && r <= 0x11ff, r >= 0xd7cb && r <= 0xd7fb:
+		return gpT
+	case r >= 0xac00 && r <= 0xd7a3:
+		if (r-0xac00)%28 == 0 {
+			return gpLV
+		}
+		return gpLVT
+	}
+	return gpOther
+}
+
+// Graphemes splits s into extended grapheme clusters, the user-perceived characters described by Unicode Standard Annex #29.
+// Prepend characters are not supported and start a new cluster.
+func Graphemes(s string) []string {
+	var clusters []string
+	start := 0
+	prev := gpOther
+	// emoji is 1 after an Extended_Pictographic rune followed by Extend runes, and 2 if a ZWJ follows that sequence.
+	emoji := 0
+	// ri is the number of consecutive Regional_Indicator runes before the current rune.
+	ri := 0
+	for i, r := range s {
+		p := graphemePropertyOf(r)
+		pict := unicode.Is(extendedPictographic, r)
+		if i > 0 && isGraphemeBreak(prev, p, pict && emoji == 2, ri) {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+
+		switch {
+		case pict:
+			emoji = 1
+		case p == gpExtend && emoji == 1:
+		case p == gpZWJ && emoji == 1:
+			emoji = 2
+		default:
+			emoji = 0
+		}
+		if p == gpRegionalIndicator {
+			ri++
+		} else {
+			ri = 0
+		}
+		prev = p
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+// isGraphemeBreak reports whether there is a grapheme cluster boundary between runes with the properties prev and next.
+// zwjSequence is true if next is an Extended_Pictographic rune continuing an emoji ZWJ sequence.
+func isGraphemeBreak(prev, next graphemeProperty, zwjSequence bool, ri int) bool {
+	switch {
+	case prev == gpCR && next == gpLF:
+		return false
+	case prev == gpCR, prev == gpLF, prev == gpControl:
+		return true
+	case next == gpCR, next == gpLF, next == gpControl:
+		return true
+	case prev == gpL && (next == gpL || next == gpV || next == gpLV || next == gpLVT):
+		return false
+	case (prev == gpLV || prev == gpV) && (next == gpV || next == gpT):
+		return false
+	case (prev == gpLVT || prev == gpT) && next == gpT:
+		return false
+	case next == gpExtend, next == gpZWJ, next == gpSpacingMark:
+		return false
+	case prev == gpZWJ && zwjSequence:
+		return false
+	case prev == gpRegionalIndicator && next == gpRegionalIndicator:
+		return ri%2 == 0
+	}
+	return true
+}
+
+// IsPalindromeGraphemes normalizes s and compares it grapheme cluster by grapheme cluster, so emoji sequences, flags, and characters with combining marks are compared as a whole.
+// Clusters are case folded, and clusters that are whitespace, punctuation, or control characters are ignored.
+func IsPalindromeGraphemes(s string, o UnicodeOptions) bool {
+	return isPalindromeUnits(graphemeUnits(s, o))
+}
+
+// graphemeUnits splits s into case-folded grapheme clusters, skipping whitespace, punctuation, and control characters.
+func graphemeUnits(s string, o UnicodeOptions) []string {
+	if o.StripDiacritics {
+		s = stripDiacritics(s)
+	}
+	s = o.Form.form().String(s)
+
+	var units []string
+	for _, g := range Graphemes(s) {
+		r := []rune(g)[0]
+		if unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsControl(r) {
+			continue
+		}
+		units = append(units, strings.Map(foldRune, g))
+	}
+	return units
+}
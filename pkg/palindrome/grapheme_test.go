@@ -0,0 +1,138 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphemes(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want []string
+	}{
+		{
+			"empty string",
+			"",
+			nil,
+		},
+		{
+			"ascii",
+			"ab",
+			[]string{"a", "b"},
+		},
+		{
+			"CRLF",
+			"a\r\nb",
+			[]string{"a", "\r\n", "b"},
+		},
+		{
+			"combining marks",
+			"e\u0301e\u0301",
+			[]string{"e\u0301", "e\u0301"},
+		},
+		{
+			"emoji ZWJ sequence",
+			"\U0001F468\u200d\U0001F469\u200d\U0001F467!",
+			[]string{"\U0001F468\u200d\U0001F469\u200d\U0001F467", "!"},
+		},
+		{
+			"emoji modifier",
+			"\U0001F44D\U0001F3FD\U0001F44D",
+			[]string{"\U0001F44D\U0001F3FD", "\U0001F44D"},
+		},
+		{
+			"emoji variation selector",
+			"\u2764\ufe0fa",
+			[]string{"\u2764\ufe0f", "a"},
+		},
+		{
+			"flags",
+			"\U0001F1FA\U0001F1F8\U0001F1E8\U0001F1E6\U0001F1FA",
+			[]string{"\U0001F1FA\U0001F1F8", "\U0001F1E8\U0001F1E6", "\U0001F1FA"},
+		},
+		{
+			"hangul jamo",
+			"\u1100\u1161\u11a8\u1100",
+			[]string{"\u1100\u1161\u11a8", "\u1100"},
+		},
+		{
+			"ZWJ without emoji",
+			"a\u200d\U0001F469",
+			[]string{"a\u200d", "\U0001F469"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := Graphemes(tc.s)
+			require.Equal(t, tc.want, g)
+		})
+	}
+}
+
+func TestIsPalindromeGraphemes(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		o    UnicodeOptions
+		want bool
+	}{
+		{
+			"empty string",
+			"",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"emoji ZWJ sequence",
+			"\U0001F468\u200d\U0001F469\u200d\U0001F467 a \U0001F468\u200d\U0001F469\u200d\U0001F467",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"different emoji ZWJ sequences",
+			"\U0001F468\u200d\U0001F469\u200d\U0001F467 a \U0001F467\u200d\U0001F469\u200d\U0001F468",
+			UnicodeOptions{},
+			false,
+		},
+		{
+			"flags",
+			"\U0001F1FA\U0001F1F8\U0001F1E8\U0001F1E6\U0001F1FA\U0001F1F8",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"combining marks NFD",
+			"e\u0301e\u0301",
+			UnicodeOptions{Form: NFD},
+			true,
+		},
+		{
+			"emoji with skin tone",
+			"Wow \U0001F44D\U0001F3FD \U0001F44D\U0001F3FD, wow!",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"emoji with different skin tones",
+			"\U0001F44D\U0001F3FD\U0001F44D\U0001F3FB",
+			UnicodeOptions{},
+			false,
+		},
+		{
+			"palindrome with diacritics",
+			"Ésope reste ici et se repose",
+			UnicodeOptions{StripDiacritics: true},
+			true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := IsPalindromeGraphemes(tc.s, tc.o)
+			require.Equal(t, tc.want, b)
+		})
+	}
+}
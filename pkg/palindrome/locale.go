@@ -0,0 +1,188 @@
+package palindrome
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// ErrInvalidLocale is returned if a language tag is not a well-formed BCP 47 tag.
+var ErrInvalidLocale = errors.New("invalid locale")
+
+// Locale selects the language-specific case folding and equivalence rules of IsPalindromeLocale.
+// The zero Locale applies the rules that are common to most languages.
+type Locale struct {
+	tag      string
+	language string
+}
+
+// ParseLocale parses a BCP 47 language tag, like "tr" or "nl-BE". Only the language subtag selects rules, and any other subtag is kept as-is in String.
+func ParseLocale(tag string) (Locale, error) {
+	subtags := strings.Split(tag, "-")
+	language := strings.ToLower(subtags[0])
+	if l := len(language); l < 2 || l > 8 || l == 4 || !isAlpha(language) {
+		return Locale{}, ErrInvalidLocale
+	}
+	canonical := []string{language}
+	for i, subtag := range subtags[1:] {
+		if len(subtag) < 1 || len(subtag) > 8 || !isAlphanumericString(subtag) {
+			return Locale{}, ErrInvalidLocale
+		}
+		switch {
+		case i == 0 && len(subtag) == 4 && isAlpha(subtag):
+			// A script subtag, like Latn.
+			subtag = strings.ToUpper(subtag[:1]) + strings.ToLower(subtag[1:])
+		case len(subtag) == 2 && isAlpha(subtag):
+			// A region subtag, like TR.
+			subtag = strings.ToUpper(subtag)
+		default:
+			subtag = strings.ToLower(subtag)
+		}
+		canonical = append(canonical, subtag)
+	}
+	return Locale{
+		tag:      strings.Join(canonical, "-"),
+		language: language,
+	}, nil
+}
+
+// String returns the canonical language tag of l, or an empty string for the zero Locale.
+func (l Locale) String() string {
+	return l.tag
+}
+
+func isAlpha(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if c := s[i] | 0x20; c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumericString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isAlphanumeric(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// expansions maps letters to the letters they are equivalent to in most languages.
+// The ligatures are compared as the letters they join, and ß as ss, like in Unicode full case folding.
+var expansions = map[rune][]string{
+	'ß': {"s", "s"},
+	'ẞ': {"s", "s"},
+	'æ': {"a", "e"},
+	'Æ': {"a", "e"},
+	'œ': {"o", "e"},
+	'Œ': {"o", "e"},
+	'ĳ': {"i", "j"},
+	'Ĳ': {"i", "j"},
+	'ﬀ': {"f", "f"},
+	'ﬁ': {"f", "i"},
+	'ﬂ': {"f", "l"},
+	'ﬃ': {"f", "f", "i"},
+	'ﬄ': {"f", "f", "l"},
+	'ﬅ': {"s", "t"},
+	'ﬆ': {"s", "t"},
+}
+
+// localeRules are the rules of a language that differ from the rules of most languages.
+type localeRules struct {
+	// lower converts a rune to lower case.
+	lower func(r rune) rune
+	// letters are the runes that are letters of the alphabet rather than ligatures, so they are not expanded.
+	letters string
+	// digraph is a pair of letters that is a single letter of the alphabet, so it is not reversed.
+	digraph string
+}
+
+var (
+	turkishRules = localeRules{
+		lower: unicode.TurkishCase.ToLower,
+	}
+	dutchRules = localeRules{
+		lower:   unicode.ToLower,
+		digraph: "ij",
+	}
+	nordicRules = localeRules{
+		lower:   unicode.ToLower,
+		letters: "æÆ",
+	}
+	defaultRules = localeRules{
+		lower: unicode.ToLower,
+	}
+)
+
+func (l Locale) rules() localeRules {
+	switch l.language {
+	case "tr", "az":
+		// Dotted İ and i and dotless I and ı are distinct letters.
+		return turkishRules
+	case "nl":
+		// IJ is a single letter, so "ij" is not reversed to "ji".
+		return dutchRules
+	case "da", "no", "nb", "nn", "is", "fo":
+		// Æ is a letter of the alphabet.
+		return nordicRules
+	}
+	return defaultRules
+}
+
+// IsPalindromeLocale is like IsPalindrome, but supports the letters of any script and applies the case folding and equivalence rules of l.
+// For example, "I" and "ı" are equal in Turkish, ß is equal to "ss", and the ligature "æ" is equal to "ae" unless it is a letter of the alphabet, like in Danish.
+// Combining marks are composed with the letter they follow if Unicode has a precomposed letter, like "é", and are removed otherwise, like everything other than letters and numbers.
+func IsPalindromeLocale(s string, l Locale) bool {
+	return isPalindromeUnits(localeUnits(s, l))
+}
+
+// LenientChecker returns a Checker that uses IsPalindromeLocale with l.
+// The Checker also implements Repairer.
+func LenientChecker(l Locale) Checker {
+	return unitChecker{
+		check: func(s string) bool {
+			return IsPalindromeLocale(s, l)
+		},
+		units: func(s string) ([]string, error) {
+			return localeUnits(s, l), nil
+		},
+	}
+}
+
+// localeUnits splits s into the lower case letters and numbers compared by IsPalindromeLocale.
+func localeUnits(s string, l Locale) []string {
+	rules := l.rules()
+	var units []string
+	for _, r := range norm.NFC.String(s) {
+		if !unicode.IsLetter(r) && !unicode.IsNumber(r) {
+			continue
+		}
+		if e, ok := expansions[r]; ok && !strings.ContainsRune(rules.letters, r) {
+			units = append(units, e...)
+			continue
+		}
+		units = append(units, string(rules.lower(r)))
+	}
+	if rules.digraph != "" {
+		units = mergeDigraph(units, rules.digraph)
+	}
+	return units
+}
+
+// mergeDigraph joins each pair of units that spells digraph into a single unit.
+func mergeDigraph(units []string, digraph string) []string {
+	res := units[:0]
+	for i := 0; i < len(units); i++ {
+		if i+1 < len(units) && units[i]+units[i+1] == digraph {
+			res = append(res, digraph)
+			i++
+			continue
+		}
+		res = append(res, units[i])
+	}
+	return res
+}
@@ -0,0 +1,89 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLocale(t *testing.T) {
+	testCases := []struct {
+		name   string
+		tag    string
+		want   string
+		errMsg string
+	}{
+		{"language", "tr", "tr", ""},
+		{"upper case language", "DE", "de", ""},
+		{"region", "nl-be", "nl-BE", ""},
+		{"script and region", "az-latn-az", "az-Latn-AZ", ""},
+		{"numeric region", "es-419", "es-419", ""},
+		{"undetermined", "und", "und", ""},
+		{"empty", "", "", ErrInvalidLocale.Error()},
+		{"single letter", "t", "", ErrInvalidLocale.Error()},
+		{"digits", "12", "", ErrInvalidLocale.Error()},
+		{"empty subtag", "tr-", "", ErrInvalidLocale.Error()},
+		{"long subtag", "en-abcdefghi", "", ErrInvalidLocale.Error()},
+		{"underscore", "en_US", "", ErrInvalidLocale.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := ParseLocale(tc.tag)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, l.String())
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Empty(t, l)
+			}
+		})
+	}
+}
+
+func TestIsPalindromeLocale(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		tag  string
+		want bool
+	}{
+		{"ascii", "A man, a plan, a canal: Panama", "en", true},
+		{"non-english letters", "Été", "fr", true},
+		{"diacritics are not removed", "Ésope reste ici et se repose", "fr", false},
+		{"dotless i in turkish", "Iı", "tr", true},
+		{"dotted I in turkish", "İi", "tr", true},
+		{"dotless i is not i in turkish", "Ii", "tr", false},
+		{"dotless i is i in english", "Ii", "en", true},
+		{"dotless i is not i in english", "Iı", "en", false},
+		{"sharp s in german", "Maß sam", "de", true},
+		{"capital sharp s in german", "SẞS", "de", true},
+		{"ij digraph in dutch", "ij x ij", "nl", true},
+		{"ij ligature in dutch", "ĳ x ij", "nl", true},
+		{"ij is reversed in english", "ij x ij", "en", false},
+		{"ij is reversed in dutch outside of a digraph", "ji x ij", "nl", false},
+		{"ae ligature", "æa", "en", true},
+		{"ae ligature is not reversed", "aæ", "fr", false},
+		{"oe ligature", "œ eo", "fr", true},
+		{"ae is a letter in danish", "æ ea", "da", false},
+		{"ae ligature in english", "æ ea", "en", true},
+		{"fi ligature", "ﬁ if", "en", true},
+		{"combining marks are composed", "été", "fr", true},
+		{"combining marks without a precomposed letter are removed", "q\u0301aq", "en", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			l, err := ParseLocale(tc.tag)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, IsPalindromeLocale(tc.s, l))
+			require.Equal(t, tc.want, LenientChecker(l).IsPalindrome(tc.s))
+		})
+	}
+}
+
+func TestIsPalindromeLocaleZero(t *testing.T) {
+	require.True(t, IsPalindromeLocale("Maß sam", Locale{}))
+	require.Equal(t, "", Locale{}.String())
+}
@@ -0,0 +1,59 @@
+package palindrome
+
+// Substring represents a palindromic substring of a string.
+// Offsets are measured in runes.
+type Substring struct {
+	Text   string
+	Start  int
+	End    int
+	Length int
+}
+
+// LongestSubstring returns the leftmost longest palindromic substring of s.
+// Runes are compared as-is, like IsPalindromeStrict compares bytes.
+// It uses Manacher's algorithm, which runs in linear time.
+func LongestSubstring(s string) Substring {
+	rs := []rune(s)
+	if len(rs) == 0 {
+		return Substring{}
+	}
+
+	// Interleave the runes with a separator that is not a valid rune, so even and odd length palindromes are both centered on an element of t.
+	t := make([]rune, 2*len(rs)+1)
+	for i := range t {
+		t[i] = -1
+	}
+	for i, r := range rs {
+		t[2*i+1] = r
+	}
+
+	// p[i] is the radius of the longest palindrome in t centered at i, which is also the length of the corresponding palindrome in rs.
+	p := make([]int, len(t))
+	center, right := 0, 0
+	best, bestCenter := 0, 0
+	for i := range t {
+		if i < right {
+			p[i] = right - i
+			if mirror := p[2*center-i]; mirror < p[i] {
+				p[i] = mirror
+			}
+		}
+		for i-p[i]-1 >= 0 && i+p[i]+1 < len(t) && t[i-p[i]-1] == t[i+p[i]+1] {
+			p[i]++
+		}
+		if i+p[i] > right {
+			center, right = i, i+p[i]
+		}
+		if p[i] > best {
+			best, bestCenter = p[i], i
+		}
+	}
+
+	start := (bestCenter - best) / 2
+	return Substring{
+		Text:   string(rs[start : start+best]),
+		Start:  start,
+		End:    start + best,
+		Length: best,
+	}
+}
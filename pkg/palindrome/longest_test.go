@@ -0,0 +1,81 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLongestSubstring(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want Substring
+	}{
+		{
+			"empty string",
+			"",
+			Substring{},
+		},
+		{
+			"single character",
+			"a",
+			Substring{"a", 0, 1, 1},
+		},
+		{
+			"no repeated characters",
+			"abc",
+			Substring{"a", 0, 1, 1},
+		},
+		{
+			"odd length",
+			"xracecary",
+			Substring{"racecar", 1, 8, 7},
+		},
+		{
+			"even length",
+			"abccbd",
+			Substring{"bccb", 1, 5, 4},
+		},
+		{
+			"leftmost",
+			"abaxcdc",
+			Substring{"aba", 0, 3, 3},
+		},
+		{
+			"whole string",
+			"step on no pets",
+			Substring{"step on no pets", 0, 15, 15},
+		},
+		{
+			"runes",
+			"xétéy",
+			Substring{"été", 1, 4, 3},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := LongestSubstring(tc.s)
+			require.Equal(t, tc.want, sub)
+		})
+	}
+}
+
+func TestLongestSubstringMatchesBruteForce(t *testing.T) {
+	for _, s := range []string{"", "aaaa", "abacabadabacaba", "forgeeksskeegfor", "banana", "cbbd", "abcdefgfedcbaxyz"} {
+		want := 0
+		rs := []rune(s)
+		for i := range rs {
+			for j := i; j <= len(rs); j++ {
+				if IsPalindromeStrict(string(rs[i:j])) && j-i > want {
+					want = j - i
+				}
+			}
+		}
+		sub := LongestSubstring(s)
+		require.Equal(t, want, sub.Length, s)
+		require.True(t, IsPalindromeStrict(sub.Text), s)
+		require.Equal(t, string(rs[sub.Start:sub.End]), sub.Text, s)
+	}
+}
@@ -0,0 +1,82 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPalindromeMirror(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"empty", "", true},
+		{"brackets", "(xox)", true},
+		{"nested brackets", "[<{()}>]", true},
+		{"mirrored letters", "bid", true},
+		{"symmetric letters", "TOOT", true},
+		{"math symbols", "x≤x≥x", true},
+		{"mirrored math symbols", "∈x∋", true},
+		{"CJK brackets", "「w」", true},
+		{"slashes", "/\\", true},
+		{"not mirrored", "((", false},
+		{"asymmetric letter", "bob", false},
+		{"no counterpart", "racecar", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, IsPalindromeMirror(tc.s))
+		})
+	}
+}
+
+func TestIsPalindromeRotation(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"empty", "", true},
+		{"pod", "pod", true},
+		{"SWIMS", "SWIMS", true},
+		{"NOON", "NOON", true},
+		{"digits", "6889", true},
+		{"brackets", "(o)", true},
+		{"bod", "bod", false},
+		{"no counterpart", "racecar", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, IsPalindromeRotation(tc.s))
+		})
+	}
+}
+
+func TestGlyphTableApply(t *testing.T) {
+	s, ok := RotationGlyphs().Apply("bod")
+	require.True(t, ok)
+	require.Equal(t, "poq", s)
+
+	s, ok = MirrorGlyphs().Apply("a")
+	require.False(t, ok)
+	require.Empty(t, s)
+}
+
+func TestGlyphTable(t *testing.T) {
+	g := MirrorGlyphs()
+	require.False(t, g.IsPalindrome("ewe"))
+	g.AddPair('e', 'ɘ')
+	g['w'] = 'w'
+	require.True(t, g.IsPalindrome("eɘ"))
+	require.False(t, g.IsPalindrome("ewe"))
+	// The package tables are not modified by a copy.
+	require.False(t, IsPalindromeMirror("eɘ"))
+
+	c := NewGlyphTable("ab")
+	require.True(t, c.IsPalindrome("aa"))
+	require.False(t, c.IsPalindrome("ab"))
+}
@@ -0,0 +1,64 @@
+package palindrome
+
+import (
+	"errors"
+	"math/big"
+)
+
+// ErrInvalidNumber is returned if a string is not a non-negative decimal integer.
+var ErrInvalidNumber = errors.New("invalid number")
+
+// Bases supported by IsPalindromeBase.
+const (
+	MinBase = 2
+	MaxBase = 36
+)
+
+// ParseNumber parses s as a non-negative decimal integer of any size.
+func ParseNumber(s string) (*big.Int, error) {
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok || n.Sign() < 0 {
+		return nil, ErrInvalidNumber
+	}
+	return n, nil
+}
+
+// IsPalindromeNumber checks if s is a non-negative decimal integer whose decimal digits are a palindrome.
+// Leading zeros are ignored, so "0110" is not a palindrome.
+func IsPalindromeNumber(s string) bool {
+	n, err := ParseNumber(s)
+	if err != nil {
+		return false
+	}
+	return IsPalindromeBase(n, 10)
+}
+
+// numberUnits returns the decimal digits of s compared by IsPalindromeNumber, without leading zeros.
+func numberUnits(s string) ([]string, error) {
+	n, err := ParseNumber(s)
+	if err != nil {
+		return nil, err
+	}
+	return strictUnits(n.Text(10)), nil
+}
+
+// IsPalindromeBase checks if the digits of n in base are a palindrome. It returns false if base is not between MinBase and MaxBase or n is negative.
+func IsPalindromeBase(n *big.Int, base int) bool {
+	if base < MinBase || base > MaxBase || n.Sign() < 0 {
+		return false
+	}
+	return IsPalindromeStrict(n.Text(base))
+}
+
+// PalindromicBases returns the bases between MinBase and MaxBase in which the digits of n are a palindrome, in ascending order.
+// For example, 585 is 1001001001 in base 2, 1111 in base 8, and 585 in base 10.
+// A number is a single digit, and so a palindrome, in every base larger than it.
+func PalindromicBases(n *big.Int) []int {
+	var bases []int
+	for base := MinBase; base <= MaxBase; base++ {
+		if IsPalindromeBase(n, base) {
+			bases = append(bases, base)
+		}
+	}
+	return bases
+}
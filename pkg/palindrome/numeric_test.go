@@ -0,0 +1,98 @@
+package palindrome
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNumber(t *testing.T) {
+	testCases := []struct {
+		name   string
+		s      string
+		want   string
+		errMsg string
+	}{
+		{"zero", "0", "0", ""},
+		{"leading zeros", "0585", "585", ""},
+		{"large number", "123456789012345678901234567890", "123456789012345678901234567890", ""},
+		{"empty", "", "", ErrInvalidNumber.Error()},
+		{"negative", "-121", "", ErrInvalidNumber.Error()},
+		{"not a number", "racecar", "", ErrInvalidNumber.Error()},
+		{"fraction", "1.5", "", ErrInvalidNumber.Error()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			n, err := ParseNumber(tc.s)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, n.String())
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+				require.Nil(t, n)
+			}
+		})
+	}
+}
+
+func TestIsPalindromeNumber(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{"zero", "0", true},
+		{"palindrome", "585", true},
+		{"not a palindrome", "10", false},
+		{"leading zeros", "0110", false},
+		{"large number", "98765432123456789098765432123456789", true},
+		{"negative", "-121", false},
+		{"not a number", "racecar", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, IsPalindromeNumber(tc.s))
+		})
+	}
+}
+
+func TestIsPalindromeBase(t *testing.T) {
+	testCases := []struct {
+		name string
+		n    int64
+		base int
+		want bool
+	}{
+		{"base 2", 585, 2, true},
+		{"base 10", 585, 10, true},
+		{"base 36", 36*36 + 35*36 + 1, 36, true},
+		{"not a palindrome", 585, 3, false},
+		{"base too small", 1, 1, false},
+		{"base too large", 1, 37, false},
+		{"negative", -1, 10, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, IsPalindromeBase(big.NewInt(tc.n), tc.base))
+		})
+	}
+}
+
+func TestPalindromicBases(t *testing.T) {
+	require.Equal(t, []int{2, 8, 10}, PalindromicBases(big.NewInt(585)))
+	require.Equal(t, []int{9, 27}, PalindromicBases(big.NewInt(1000)))
+	require.Equal(t, []int{3, 4, 9, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23, 24, 25, 26, 27, 28, 29, 30, 31, 32, 33, 34, 35, 36}, PalindromicBases(big.NewInt(10)))
+
+	// 2^100+1 is 1 followed by 99 zeros and a 1 in base 2, but not a palindrome in base 10.
+	n := new(big.Int).Lsh(big.NewInt(1), 100)
+	n.Add(n, big.NewInt(1))
+	bases := PalindromicBases(n)
+	require.Contains(t, bases, 2)
+	require.Contains(t, bases, 4)
+	require.NotContains(t, bases, 10)
+}
@@ -1,11 +1,6 @@
 // Package palindrome implements utilities to check if a string is a palindrome.
 package palindrome
 
-import (
-	"regexp"
-	"strings"
-)
-
 // IsPalindromeStrict returns true if s is a palindrome.
 // An empty string is a palindrome;
 // a single character is a palindrome;
@@ -21,10 +16,57 @@ func IsPalindromeStrict(s string) bool {
 	return true
 }
 
-// IsPalindrome converts s to lowercase and removes non-alphanumeric characters and whitespace from s before calling IsPalindromeStrict(s).
+// IsPalindrome returns true if s is a palindrome after converting it to lowercase and removing everything other than English letters and digits.
+// The bytes are compared in place from both ends, so it does not allocate.
 func IsPalindrome(s string) bool {
-	r := regexp.MustCompile("[^a-zA-Z0-9]+")
-	s = r.ReplaceAllString(s, "")
-	s = strings.ToLower(s)
-	return IsPalindromeStrict(s)
+	i, j := 0, len(s)-1
+	for {
+		for i < j && !isAlphanumeric(s[i]) {
+			i++
+		}
+		for i < j && !isAlphanumeric(s[j]) {
+			j--
+		}
+		if i >= j {
+			return true
+		}
+		if toLower(s[i]) != toLower(s[j]) {
+			return false
+		}
+		i++
+		j--
+	}
+}
+
+// strictUnits returns the bytes of s compared by IsPalindromeStrict.
+func strictUnits(s string) []string {
+	units := make([]string, len(s))
+	for i := range units {
+		units[i] = s[i : i+1]
+	}
+	return units
+}
+
+// lenientUnits returns the lower case English letters and digits of s compared by IsPalindrome.
+func lenientUnits(s string) []string {
+	var units []string
+	for i := 0; i < len(s); i++ {
+		if isAlphanumeric(s[i]) {
+			units = append(units, string(toLower(s[i])))
+		}
+	}
+	return units
+}
+
+// isAlphanumeric reports whether c is an English letter or a digit.
+func isAlphanumeric(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// toLower converts an English upper case letter to lower case, and returns any other byte unchanged.
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
 }
@@ -1,6 +1,9 @@
 package palindrome
 
 import (
+	"math/rand"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -88,6 +91,26 @@ func TestIsPalindrome(t *testing.T) {
 			"a toyota",
 			true,
 		},
+		{
+			"only special characters",
+			"!?",
+			true,
+		},
+		{
+			"special characters at both ends",
+			"!!a, b!b a??",
+			true,
+		},
+		{
+			"not a palindrome",
+			"abc",
+			false,
+		},
+		{
+			"non-english letters are removed",
+			"aéb",
+			false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -97,3 +120,61 @@ func TestIsPalindrome(t *testing.T) {
 		})
 	}
 }
+
+// isPalindromeRegexp is the previous implementation of IsPalindrome, which IsPalindrome must agree with.
+func isPalindromeRegexp(s string) bool {
+	r := regexp.MustCompile("[^a-zA-Z0-9]+")
+	s = r.ReplaceAllString(s, "")
+	s = strings.ToLower(s)
+	return IsPalindromeStrict(s)
+}
+
+// randomLenientStrings returns n random strings that are palindromes about half of the time.
+func randomLenientStrings(n int) []string {
+	r := rand.New(rand.NewSource(1))
+	alphabet := "aAbB1 ,.!é"
+	ss := make([]string, n)
+	for i := range ss {
+		b := make([]byte, r.Intn(20))
+		for j := range b {
+			b[j] = alphabet[r.Intn(len(alphabet))]
+		}
+		if i%2 == 0 {
+			for j := 0; j < len(b)/2; j++ {
+				b[len(b)-1-j] = b[j]
+			}
+		}
+		ss[i] = string(b)
+	}
+	return ss
+}
+
+func TestIsPalindromeRegexp(t *testing.T) {
+	for _, s := range randomLenientStrings(10000) {
+		require.Equal(t, isPalindromeRegexp(s), IsPalindrome(s), "%q", s)
+	}
+}
+
+func TestIsPalindromeAllocs(t *testing.T) {
+	s := strings.Repeat("A man, a plan, a canal: Panama! ", 10)
+	allocs := testing.AllocsPerRun(100, func() {
+		IsPalindrome(s)
+	})
+	require.Zero(t, allocs)
+}
+
+var benchmarkString = "A man, a plan, a canal: Panama! " + strings.Repeat("x", 64) + " !amanaP :lanac a ,nalp a ,nam A"
+
+func BenchmarkIsPalindrome(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		IsPalindrome(benchmarkString)
+	}
+}
+
+func BenchmarkIsPalindromeRegexp(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		isPalindromeRegexp(benchmarkString)
+	}
+}
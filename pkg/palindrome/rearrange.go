@@ -0,0 +1,40 @@
+package palindrome
+
+// Rearrange returns a palindrome made of the characters of s, and false if there is none.
+// Like IsPalindrome, s is converted to lowercase and non-alphanumeric characters and whitespace are removed from s first.
+// The characters of the first half of the palindrome are in ascending order.
+func Rearrange(s string) (string, bool) {
+	var counts [256]int
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if c, ok := streamByte(s[i], false); ok {
+			counts[c]++
+			n++
+		}
+	}
+
+	var middle byte
+	odd := false
+	for c, count := range counts {
+		if count%2 == 1 {
+			if odd {
+				return "", false
+			}
+			middle, odd = byte(c), true
+		}
+	}
+
+	b := make([]byte, n)
+	i := 0
+	for c, count := range counts {
+		for k := 0; k < count/2; k++ {
+			b[i] = byte(c)
+			b[n-i-1] = byte(c)
+			i++
+		}
+	}
+	if odd {
+		b[i] = middle
+	}
+	return string(b), true
+}
@@ -0,0 +1,70 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRearrange(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want string
+		ok   bool
+	}{
+		{
+			"empty string",
+			"",
+			"",
+			true,
+		},
+		{
+			"single character",
+			"a",
+			"a",
+			true,
+		},
+		{
+			"palindrome",
+			"racecar",
+			"acrerca",
+			true,
+		},
+		{
+			"rearrangeable",
+			"Tact Coa!",
+			"actotca",
+			true,
+		},
+		{
+			"even length",
+			"aabb",
+			"abba",
+			true,
+		},
+		{
+			"digits",
+			"1212",
+			"1221",
+			true,
+		},
+		{
+			"not rearrangeable",
+			"abc",
+			"",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, ok := Rearrange(tc.s)
+			require.Equal(t, tc.ok, ok)
+			require.Equal(t, tc.want, s)
+			if ok {
+				require.True(t, IsPalindrome(s))
+			}
+		})
+	}
+}
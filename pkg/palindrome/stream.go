@@ -0,0 +1,168 @@
+package palindrome
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+const streamBufferSize = 32 * 1024
+
+// IsPalindromeReaderAt checks if the first size bytes of r are a palindrome without reading them into memory.
+// If strict is true, bytes are compared like IsPalindromeStrict, otherwise like IsPalindrome.
+// r is read from both ends towards the middle with fixed size buffers, so every byte is read at most once.
+func IsPalindromeReaderAt(r io.ReaderAt, size int64, strict bool) (bool, error) {
+	front := bufio.NewReaderSize(io.NewSectionReader(r, 0, size), streamBufferSize)
+	back := &reverseReader{
+		r:   r,
+		buf: make([]byte, streamBufferSize),
+		off: size,
+	}
+
+	// The bytes before i have been read from the front, and the bytes from j onwards have been read from the back.
+	i, j := int64(0), size
+	for {
+		var a byte
+		for {
+			if i >= j {
+				return true, nil
+			}
+			c, err := front.ReadByte()
+			if err != nil {
+				return false, err
+			}
+			i++
+			if k, ok := streamByte(c, strict); ok {
+				a = k
+				break
+			}
+		}
+
+		var b byte
+		for {
+			if j <= i {
+				return true, nil
+			}
+			c, err := back.ReadByte()
+			if err != nil {
+				return false, err
+			}
+			j--
+			if k, ok := streamByte(c, strict); ok {
+				b = k
+				break
+			}
+		}
+
+		if a != b {
+			return false, nil
+		}
+	}
+}
+
+// reverseReader reads the bytes of r before off in reverse order.
+type reverseReader struct {
+	r   io.ReaderAt
+	buf []byte
+	// off is the offset of buf[0] in r, and buf[:n] are the unread bytes.
+	off int64
+	n   int
+}
+
+func (rr *reverseReader) ReadByte() (byte, error) {
+	if rr.n == 0 {
+		if rr.off == 0 {
+			return 0, io.EOF
+		}
+		size := int64(len(rr.buf))
+		if rr.off < size {
+			size = rr.off
+		}
+		rr.off -= size
+		n, err := rr.r.ReadAt(rr.buf[:size], rr.off)
+		if int64(n) < size {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+		rr.n = n
+	}
+	rr.n--
+	return rr.buf[rr.n], nil
+}
+
+// IsPalindromeReader checks if the bytes read from r until io.EOF are a palindrome in a single pass and constant memory.
+// If strict is true, bytes are compared like IsPalindromeStrict, otherwise like IsPalindrome.
+// The bytes are compared by polynomial hashes modulo 2^61-1 with a random base, so a string that is not a palindrome is reported as one with a probability of at most n/2^61 for n bytes.
+func IsPalindromeReader(r io.Reader, strict bool) (bool, error) {
+	base, err := randomBase()
+	if err != nil {
+		return false, err
+	}
+
+	// forward is the hash of the bytes in reading order, and backward is the hash of the bytes in reverse order.
+	var forward, backward uint64
+	pow := uint64(1)
+	br := bufio.NewReaderSize(r, streamBufferSize)
+	for {
+		c, err := br.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		k, ok := streamByte(c, strict)
+		if !ok {
+			continue
+		}
+		v := uint64(k) + 1
+		forward = addMod(mulMod(forward, base), v)
+		backward = addMod(backward, mulMod(v, pow))
+		pow = mulMod(pow, base)
+	}
+	return forward == backward, nil
+}
+
+// streamByte returns the byte to compare for c, and false if c is ignored.
+func streamByte(c byte, strict bool) (byte, bool) {
+	if strict {
+		return c, true
+	}
+	if !isAlphanumeric(c) {
+		return 0, false
+	}
+	return toLower(c), true
+}
+
+const hashModulus = 1<<61 - 1
+
+func randomBase() (uint64, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return 0, err
+	}
+	// Use a base of at least 256, so distinct bytes never collide.
+	return 256 + binary.LittleEndian.Uint64(b[:])%(hashModulus-256), nil
+}
+
+func mulMod(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	// a*b = hi*2^64 + lo, and 2^64 = 8 (mod 2^61-1).
+	r := (hi<<3 | lo>>61) + lo&hashModulus
+	for r >= hashModulus {
+		r -= hashModulus
+	}
+	return r
+}
+
+func addMod(a, b uint64) uint64 {
+	r := a + b
+	if r >= hashModulus {
+		r -= hashModulus
+	}
+	return r
+}
@@ -0,0 +1,86 @@
+package palindrome
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// streamTestCases returns strings checked by both streaming checkers, including strings larger than their buffers.
+func streamTestCases() []string {
+	half := strings.Repeat("Was it a car or a cat I saw? ", 5000)
+	var b strings.Builder
+	for i := len(half) - 1; i >= 0; i-- {
+		b.WriteByte(half[i])
+	}
+	large := half + b.String()
+	return []string{
+		"",
+		"a",
+		"racecar",
+		"racecar!",
+		"Racecar",
+		"a toyota",
+		"abc",
+		"!!!",
+		"ab!!!!ba",
+		large,
+		large[1:],
+		large + "x",
+	}
+}
+
+func TestIsPalindromeReaderAt(t *testing.T) {
+	for _, s := range streamTestCases() {
+		for _, strict := range []bool{false, true} {
+			want := IsPalindrome(s)
+			if strict {
+				want = IsPalindromeStrict(s)
+			}
+			b, err := IsPalindromeReaderAt(strings.NewReader(s), int64(len(s)), strict)
+			require.NoError(t, err)
+			require.Equal(t, want, b, "%.20q strict=%v", s, strict)
+		}
+	}
+}
+
+func TestIsPalindromeReaderAtError(t *testing.T) {
+	b, err := IsPalindromeReaderAt(strings.NewReader("abc"), 10, true)
+	require.Error(t, err)
+	require.False(t, b)
+}
+
+func TestIsPalindromeReader(t *testing.T) {
+	for _, s := range streamTestCases() {
+		for _, strict := range []bool{false, true} {
+			want := IsPalindrome(s)
+			if strict {
+				want = IsPalindromeStrict(s)
+			}
+			b, err := IsPalindromeReader(strings.NewReader(s), strict)
+			require.NoError(t, err)
+			require.Equal(t, want, b, "%.20q strict=%v", s, strict)
+		}
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("error")
+}
+
+func TestIsPalindromeReaderError(t *testing.T) {
+	b, err := IsPalindromeReader(errReader{}, true)
+	require.Error(t, err)
+	require.Equal(t, "error", err.Error())
+	require.False(t, b)
+}
+
+func TestMulMod(t *testing.T) {
+	require.Equal(t, uint64(6), mulMod(2, 3))
+	require.Equal(t, uint64(1), mulMod(hashModulus-1, hashModulus-1))
+	require.Equal(t, uint64(0), mulMod(hashModulus-1, 0))
+}
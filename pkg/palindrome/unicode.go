@@ -0,0 +1,120 @@
+package palindrome
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrInvalidForm is returned if a normalization form is not supported.
+	ErrInvalidForm = errors.New("invalid normalization form")
+)
+
+// Form is a Unicode normalization form.
+type Form int
+
+const (
+	// NFC is the canonical composition form.
+	NFC Form = iota
+	// NFD is the canonical decomposition form.
+	NFD
+)
+
+// ParseForm returns the Form named by s. The name is case-insensitive.
+func ParseForm(s string) (Form, error) {
+	switch strings.ToUpper(s) {
+	case "NFC":
+		return NFC, nil
+	case "NFD":
+		return NFD, nil
+	}
+	return NFC, ErrInvalidForm
+}
+
+// String returns the name of f.
+func (f Form) String() string {
+	if f == NFD {
+		return "NFD"
+	}
+	return "NFC"
+}
+
+func (f Form) form() norm.Form {
+	if f == NFD {
+		return norm.NFD
+	}
+	return norm.NFC
+}
+
+// UnicodeOptions configures IsPalindromeUnicode.
+type UnicodeOptions struct {
+	// Form is the normalization form applied to s before comparison.
+	Form Form
+	// StripDiacritics removes nonspacing marks, so "é" and "e" are equal.
+	StripDiacritics bool
+}
+
+// IsPalindromeUnicode normalizes s, case folds it and removes everything except letters and numbers from s before checking if it is a palindrome.
+// Unlike IsPalindrome, s is compared rune by rune instead of byte by byte, and any script is supported.
+// A combining mark is compared together with the letter or number it follows.
+func IsPalindromeUnicode(s string, o UnicodeOptions) bool {
+	return isPalindromeUnits(unicodeUnits(s, o))
+}
+
+// unicodeUnits splits s into case-folded letters and numbers, each followed by its combining marks.
+func unicodeUnits(s string, o UnicodeOptions) []string {
+	if o.StripDiacritics {
+		s = stripDiacritics(s)
+	}
+	s = o.Form.form().String(s)
+
+	var units []string
+	keep := false
+	for _, r := range s {
+		if unicode.Is(unicode.M, r) {
+			if keep {
+				units[len(units)-1] += string(r)
+			}
+			continue
+		}
+		keep = unicode.IsLetter(r) || unicode.IsNumber(r)
+		if keep {
+			units = append(units, string(foldRune(r)))
+		}
+	}
+	return units
+}
+
+// stripDiacritics decomposes s and removes its nonspacing marks.
+func stripDiacritics(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, norm.NFD.String(s))
+}
+
+// foldRune returns the smallest rune in the case folding orbit of r, so runes that are equal under case folding map to the same rune.
+func foldRune(r rune) rune {
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+func isPalindromeUnits(units []string) bool {
+	l := len(units)
+	for i := 0; i < l/2; i++ {
+		if units[i] != units[l-i-1] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseForm(t *testing.T) {
+	testCases := []struct {
+		name   string
+		s      string
+		want   Form
+		errMsg string
+	}{
+		{
+			"NFC",
+			"NFC",
+			NFC,
+			"",
+		},
+		{
+			"lower case NFD",
+			"nfd",
+			NFD,
+			"",
+		},
+		{
+			"ErrInvalidForm",
+			"NFKC",
+			NFC,
+			ErrInvalidForm.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := ParseForm(tc.s)
+			if tc.errMsg == "" {
+				require.NoError(t, err)
+				require.Equal(t, tc.want, f)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tc.errMsg, err.Error())
+			}
+		})
+	}
+}
+
+func TestIsPalindromeUnicode(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		o    UnicodeOptions
+		want bool
+	}{
+		{
+			"empty string",
+			"",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"palindrome with upper case and whitespace",
+			"A Toyota",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"palindrome with diacritics",
+			"Ésope reste ici et se repose",
+			UnicodeOptions{StripDiacritics: true},
+			true,
+		},
+		{
+			"diacritics are significant",
+			"Ésope reste ici et se repose",
+			UnicodeOptions{},
+			false,
+		},
+		{
+			"decomposed characters",
+			"e\u0301te\u0301",
+			UnicodeOptions{Form: NFC},
+			true,
+		},
+		{
+			"composed and decomposed characters",
+			"\u00e9te\u0301",
+			UnicodeOptions{Form: NFC},
+			true,
+		},
+		{
+			"composed and decomposed characters NFD",
+			"\u00e9te\u0301",
+			UnicodeOptions{Form: NFD},
+			true,
+		},
+		{
+			"different combining marks",
+			"\u00e9t\u00e8",
+			UnicodeOptions{Form: NFD},
+			false,
+		},
+		{
+			"cyrillic palindrome",
+			"А роза упала на лапу Азора",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"greek non-palindrome",
+			"Καλημέρα",
+			UnicodeOptions{},
+			false,
+		},
+		{
+			"CJK palindrome",
+			"上海自来水来自海上",
+			UnicodeOptions{},
+			true,
+		},
+		{
+			"CJK non-palindrome",
+			"你好",
+			UnicodeOptions{},
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := IsPalindromeUnicode(tc.s, tc.o)
+			require.Equal(t, tc.want, b)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package palindrome
+
+import (
+	"strings"
+	"unicode"
+)
+
+// IsPalindromeWords returns true if the words of s read the same forwards and backwards, like "Fall leaves after leaves fall".
+// A word is a run of letters, numbers, and apostrophes. Words are case folded, and apostrophes are removed from them.
+func IsPalindromeWords(s string) bool {
+	return isPalindromeUnits(words(s))
+}
+
+// IsPalindromeLines returns true if the lines of s read the same forwards and backwards.
+// Lines are compared by their words as in IsPalindromeWords, and lines without words are ignored.
+func IsPalindromeLines(s string) bool {
+	return isPalindromeUnits(lines(s))
+}
+
+// lines splits s into lines of case-folded words separated by spaces, skipping lines without words.
+func lines(s string) []string {
+	var res []string
+	for _, l := range strings.Split(s, "\n") {
+		w := words(l)
+		if len(w) == 0 {
+			continue
+		}
+		res = append(res, strings.Join(w, " "))
+	}
+	return res
+}
+
+// words splits s into case-folded words.
+func words(s string) []string {
+	var res []string
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r), unicode.IsNumber(r), unicode.Is(unicode.M, r):
+			b.WriteRune(foldRune(r))
+		case r == '\'', r == '’':
+		default:
+			if b.Len() > 0 {
+				res = append(res, b.String())
+				b.Reset()
+			}
+		}
+	}
+	if b.Len() > 0 {
+		res = append(res, b.String())
+	}
+	return res
+}
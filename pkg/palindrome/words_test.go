@@ -0,0 +1,94 @@
+package palindrome
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPalindromeWords(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{
+			"empty string",
+			"",
+			true,
+		},
+		{
+			"single word",
+			"racecar",
+			true,
+		},
+		{
+			"word palindrome",
+			"Fall leaves after leaves fall",
+			true,
+		},
+		{
+			"word palindrome with punctuation",
+			"King, are you glad you are king?",
+			true,
+		},
+		{
+			"word palindrome with apostrophes",
+			"You can't stop, can’t you?",
+			true,
+		},
+		{
+			"character palindrome",
+			"a toyota",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := IsPalindromeWords(tc.s)
+			require.Equal(t, tc.want, b)
+		})
+	}
+}
+
+func TestIsPalindromeLines(t *testing.T) {
+	testCases := []struct {
+		name string
+		s    string
+		want bool
+	}{
+		{
+			"empty string",
+			"",
+			true,
+		},
+		{
+			"single line",
+			"not a palindrome",
+			true,
+		},
+		{
+			"line palindrome",
+			"Dawn breaks\nthe river runs\nDawn breaks",
+			true,
+		},
+		{
+			"line palindrome with blank lines and punctuation",
+			"Dawn breaks.\r\n\r\nThe river runs;\n  the river runs\n\ndawn, breaks!\n",
+			true,
+		},
+		{
+			"word palindrome",
+			"Fall leaves\nafter leaves fall",
+			false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b := IsPalindromeLines(tc.s)
+			require.Equal(t, tc.want, b)
+		})
+	}
+}